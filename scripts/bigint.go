@@ -0,0 +1,70 @@
+package scripts
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// parseBigInteger parses an integer literal of arbitrary size with an optional 0x, 0o or 0b prefix
+func parseBigInteger(token string) (*big.Int, error) {
+	base := 10
+	digits := token
+	switch {
+	case strings.HasPrefix(token, "0x"):
+		base = 16
+		digits = token[2:]
+	case strings.HasPrefix(token, "0o"):
+		base = 8
+		digits = token[2:]
+	case strings.HasPrefix(token, "0b"):
+		base = 2
+		digits = token[2:]
+	}
+
+	value, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a valid integer", token)
+	}
+
+	return value, nil
+}
+
+// toBigInteger converts an integral value, a decimal or a string to a big integer
+func toBigInteger(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return v, nil
+	case Char:
+		return big.NewInt(int64(v)), nil
+	case int, int8, int16, int32, int64:
+		return big.NewInt(cast.ToInt64(v)), nil
+	case uint, uint8, uint16, uint32, uint64:
+		return new(big.Int).SetUint64(cast.ToUint64(v)), nil
+	case float32, float64:
+		float := cast.ToFloat64(v)
+		if math.IsNaN(float) || math.IsInf(float, 0) {
+			return nil, fmt.Errorf("Unable to convert '%v' to bigint", value)
+		}
+		integer, _ := big.NewFloat(float).Int(nil)
+		return integer, nil
+	case Decimal:
+		return v.Truncate(), nil
+	case bool:
+		if v {
+			return big.NewInt(1), nil
+		}
+		return big.NewInt(0), nil
+	case string:
+		integer, ok := new(big.Int).SetString(strings.TrimSpace(v), 10)
+		if !ok {
+			return nil, fmt.Errorf("Unable to convert '%v' to bigint", value)
+		}
+		return integer, nil
+	default:
+		return nil, fmt.Errorf("Unable to convert '%v' to bigint", value)
+	}
+}
@@ -2,95 +2,259 @@ package scripts
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 const CAST_BOOL = "bool"
+const CAST_SBYTE = "sbyte"
+const CAST_BYTE = "byte"
+const CAST_SHORT = "short"
+const CAST_USHORT = "ushort"
 const CAST_INT = "int"
+const CAST_UINT = "uint"
+const CAST_LONG = "long"
+const CAST_ULONG = "ulong"
+const CAST_CHAR = "char"
 const CAST_FLOAT = "float"
 const CAST_DOUBLE = "double"
+const CAST_DECIMAL = "decimal"
+const CAST_BIGINT = "bigint"
 const CAST_STRING = "string"
+const CAST_DATETIME = "datetime"
+const CAST_TIMESPAN = "timespan"
+const CAST_ARRAY = "array"
+
+// integerCasts integer types which can be cast to using the C# type name
+var integerCasts = map[string]integerSuffix{
+	CAST_SBYTE:  {unsigned: false, bits: 8},
+	CAST_BYTE:   {unsigned: true, bits: 8},
+	CAST_SHORT:  {unsigned: false, bits: 16},
+	CAST_USHORT: {unsigned: true, bits: 16},
+	CAST_INT:    {unsigned: false, bits: 32},
+	CAST_UINT:   {unsigned: true, bits: 32},
+	CAST_LONG:   {unsigned: false, bits: 64},
+	CAST_ULONG:  {unsigned: true, bits: 64},
+}
 
 // Cast casts/converts data to another type
 //
-// Some of these conversion implementation are pretty hacky and not really optimized for
-// performance. The main goal was to get it to work fast.
+// Conversions follow the explicit conversions of C#: fractional digits are truncated when
+// converting to an integer type, values which don't fit into the target type result in an
+// error instead of wrapping around. Additional target types can be registered in
+// Conversions.
 type Cast struct {
-	TargetType string
-	Data       Token
+	TargetType  string
+	Data        Token
+	conversions *Conversions
 }
 
 func castValue(value interface{}, targettype string) (interface{}, error) {
+	if integertype, ok := integerCasts[targettype]; ok {
+		return castInteger(value, targettype, integertype)
+	}
+
 	switch targettype {
-	case "bool":
-		switch value.(type) {
-		case bool:
-			return value, nil
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			return fmt.Sprintf("%d", value) != "0", nil
-		case float32, float64:
-			return fmt.Sprintf("%f", value) != "0", nil
-		default:
-			return value != nil, nil
-		}
-	case "int":
-		switch value.(type) {
+	case CAST_BOOL:
+		switch v := value.(type) {
 		case bool:
-			bvalue := value.(bool)
-			if bvalue {
-				return int64(1), nil
-			}
-			return int64(0), nil
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			return strconv.ParseInt(fmt.Sprintf("%d", value), 10, 32)
-		case float32, float64:
-			return strconv.ParseInt(fmt.Sprintf("%f", value), 10, 32)
+			return v, nil
 		case string:
-			return strconv.ParseInt(fmt.Sprintf("%s", value), 10, 32)
-		default:
-			return nil, fmt.Errorf("Unsupported cast from '%v' to '%s'", value, targettype)
-		}
-	case "float":
-		switch value.(type) {
-		case bool:
-			bvalue := value.(bool)
-			if bvalue {
-				return float32(1.0), nil
+			boolean, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("Unable to convert '%v' to '%s'", value, targettype)
 			}
-			return float32(0.0), nil
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			return strconv.ParseFloat(fmt.Sprintf("%d", value), 32)
-		case float32, float64:
-			return strconv.ParseFloat(fmt.Sprintf("%f", value), 32)
-		case string:
-			return strconv.ParseFloat(fmt.Sprintf("%s", value), 32)
+			return boolean, nil
+		}
+
+		switch numericTypeOf(value) {
+		case numericNone:
+			return value != nil, nil
+		case numericFloat, numericDouble:
+			return toFloat64(value) != 0, nil
+		case numericDecimal:
+			return value.(Decimal).Sign() != 0, nil
 		default:
-			return nil, fmt.Errorf("Unsupported cast from '%v' to '%s'", value, targettype)
+			integer, _ := toBigInteger(value)
+			return integer.Sign() != 0, nil
 		}
-	case "double":
-		switch value.(type) {
+	case CAST_CHAR:
+		return castCharacter(value)
+	case CAST_FLOAT, CAST_DOUBLE:
+		var float float64
+		switch v := value.(type) {
 		case bool:
-			bvalue := value.(bool)
-			if bvalue {
-				return 1.0, nil
+			if v {
+				float = 1.0
 			}
-			return 0.0, nil
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			return strconv.ParseFloat(fmt.Sprintf("%d", value), 64)
-		case float32, float64:
-			return strconv.ParseFloat(fmt.Sprintf("%f", value), 64)
 		case string:
-			return strconv.ParseFloat(fmt.Sprintf("%s", value), 64)
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to convert '%v' to '%s'", value, targettype)
+			}
+			float = parsed
 		default:
-			return nil, fmt.Errorf("Unsupported cast from '%v' to '%s'", value, targettype)
+			if numericTypeOf(value) == numericNone {
+				return nil, fmt.Errorf("Unsupported cast from '%v' to '%s'", value, targettype)
+			}
+			float = toFloat64(value)
+		}
+
+		if targettype == CAST_FLOAT {
+			return float32(float), nil
 		}
-	case "string":
+		return float, nil
+	case CAST_DECIMAL:
+		return toDecimal(value)
+	case CAST_BIGINT:
+		return toBigInteger(value)
+	case CAST_STRING:
 		return fmt.Sprintf("%v", value), nil
+	case CAST_DATETIME:
+		return toDateTime(value)
+	case CAST_TIMESPAN:
+		return toTimeSpan(value)
+	case CAST_ARRAY:
+		return castArray(value)
 	default:
 		return nil, fmt.Errorf("Unsupported cast target type '%s'", targettype)
 	}
 }
 
+// castInteger converts a value to an integer type
+func castInteger(value interface{}, targettype string, integertype integerSuffix) (interface{}, error) {
+	var integer *big.Int
+	switch v := value.(type) {
+	case bool:
+		integer = big.NewInt(0)
+		if v {
+			integer = big.NewInt(1)
+		}
+	case string:
+		parsed, ok := new(big.Int).SetString(strings.TrimSpace(v), 10)
+		if !ok {
+			return nil, fmt.Errorf("Unable to convert '%v' to '%s'", value, targettype)
+		}
+		integer = parsed
+	default:
+		switch numericTypeOf(value) {
+		case numericNone:
+			return nil, fmt.Errorf("Unsupported cast from '%v' to '%s'", value, targettype)
+		case numericFloat, numericDouble:
+			float := toFloat64(value)
+			if math.IsNaN(float) || math.IsInf(float, 0) {
+				return nil, fmt.Errorf("'%v' is out of range for '%s'", value, targettype)
+			}
+		}
+
+		converted, err := toBigInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		integer = converted
+	}
+
+	minimum, maximum := integerRange(integertype)
+	if integer.Cmp(minimum) < 0 || integer.Cmp(maximum) > 0 {
+		return nil, fmt.Errorf("'%v' is out of range for '%s'", value, targettype)
+	}
+
+	if integertype.unsigned {
+		unsigned := integer.Uint64()
+		switch integertype.bits {
+		case 8:
+			return uint8(unsigned), nil
+		case 16:
+			return uint16(unsigned), nil
+		case 32:
+			return uint32(unsigned), nil
+		default:
+			return unsigned, nil
+		}
+	}
+
+	signed := integer.Int64()
+	switch integertype.bits {
+	case 8:
+		return int8(signed), nil
+	case 16:
+		return int16(signed), nil
+	case 32:
+		return int32(signed), nil
+	default:
+		return signed, nil
+	}
+}
+
+// integerRange determines the minimum and maximum value of an integer type
+func integerRange(integertype integerSuffix) (*big.Int, *big.Int) {
+	if integertype.unsigned {
+		maximum := new(big.Int).Lsh(big.NewInt(1), uint(integertype.bits))
+		return big.NewInt(0), maximum.Sub(maximum, big.NewInt(1))
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(integertype.bits-1))
+	return new(big.Int).Neg(limit), new(big.Int).Sub(limit, big.NewInt(1))
+}
+
+// castCharacter converts a string consisting of a single character or a character code to a character
+func castCharacter(value interface{}) (interface{}, error) {
+	if text, ok := value.(string); ok {
+		if utf8.RuneCountInString(text) != 1 {
+			return nil, fmt.Errorf("Unable to convert '%v' to '%s' since it doesn't consist of exactly one character", value, CAST_CHAR)
+		}
+		character, _ := utf8.DecodeRuneInString(text)
+		return Char(character), nil
+	}
+
+	if !isIntegralType(numericTypeOf(value)) {
+		return nil, fmt.Errorf("Unsupported cast from '%v' to '%s'", value, CAST_CHAR)
+	}
+
+	code, _ := toBigInteger(value)
+	if code.Sign() < 0 || code.Cmp(big.NewInt(utf8.MaxRune)) > 0 {
+		return nil, fmt.Errorf("'%v' is out of range for '%s'", value, CAST_CHAR)
+	}
+	return Char(code.Int64()), nil
+}
+
+// castArray converts a value to an array
+//
+// Slices and arrays are copied, strings are split into characters, ranges are enumerated,
+// null results in an empty array and any other value in an array containing the value.
+func castArray(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case []interface{}:
+		return append([]interface{}{}, v...), nil
+	case string:
+		array := []interface{}{}
+		for _, character := range v {
+			array = append(array, Char(character))
+		}
+		return array, nil
+	case Range:
+		return v.Values()
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array:
+		array := make([]interface{}, reflected.Len())
+		for i := range array {
+			array[i] = reflected.Index(i).Interface()
+		}
+		return array, nil
+	default:
+		return []interface{}{value}, nil
+	}
+}
+
 // Execute executes the type cast
 func (cast *Cast) Execute(variables *Variables) (interface{}, error) {
 	value, err := cast.Data.Execute(variables)
@@ -99,5 +263,5 @@ func (cast *Cast) Execute(variables *Variables) (interface{}, error) {
 		return nil, err
 	}
 
-	return castValue(value, cast.TargetType)
+	return cast.conversions.Convert(value, cast.TargetType)
 }
@@ -0,0 +1,13 @@
+package scripts
+
+// Char character value like 'a'
+//
+// Characters are a type of their own so scripts can tell them from integers like in C#.
+// In arithmetic and comparisons they are treated as int, converting them to string or
+// concatenating them with strings results in the character itself.
+type Char rune
+
+// String returns the character as string
+func (character Char) String() string {
+	return string(rune(character))
+}
@@ -1,5 +1,12 @@
 package scripts
 
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
 func isWhiteSpace(character byte) bool {
 	switch character {
 	case ' ', '\t', '\r', '\n':
@@ -9,6 +16,83 @@ func isWhiteSpace(character byte) bool {
 	}
 }
 
+// isIdentifierStart determines whether a character can start an identifier
+func isIdentifierStart(character rune) bool {
+	return character == '_' || unicode.IsLetter(character)
+}
+
+// isIdentifierCharacter determines whether a character can be part of an identifier
+func isIdentifierCharacter(character rune) bool {
+	return isIdentifierStart(character) || unicode.IsDigit(character)
+}
+
+// isTokenStart determines whether the character at the specified index can start a token
+func isTokenStart(data *string, index int) bool {
+	character, _ := readRune(data, index)
+	switch character {
+	case '.', '"', '\'', '@', '#':
+		return true
+	default:
+		return isIdentifierCharacter(character)
+	}
+}
+
+// isNumberStart determines whether a number like '.5' starts at the specified index
+func isNumberStart(data *string, index int) bool {
+	if index < len(*data) && (*data)[index] == '.' {
+		index++
+	}
+	return index < len(*data) && (*data)[index] >= '0' && (*data)[index] <= '9'
+}
+
+// isExponentSign determines whether the character at the specified index is the sign
+// of an exponent in a number literal like '1e-6'
+func isExponentSign(data *string, index int) bool {
+	if index < 2 || (*data)[index] != '-' && (*data)[index] != '+' {
+		return false
+	}
+
+	if (*data)[index-1] != 'e' && (*data)[index-1] != 'E' {
+		return false
+	}
+
+	// walk back to the start of the number to ignore hex literals like 0x1e
+	start := index - 1
+	for start > 0 && (isIdentifierCharacter(rune((*data)[start-1])) || (*data)[start-1] == '.') {
+		start--
+	}
+	if strings.HasPrefix(strings.ToLower((*data)[start:]), "0x") {
+		return false
+	}
+	return (*data)[start] >= '0' && (*data)[start] <= '9' || (*data)[start] == '.'
+}
+
+// isRangeOperator determines whether a range operator like '..' starts at the specified index
+func isRangeOperator(data *string, index int) bool {
+	return strings.HasPrefix((*data)[index:], "..")
+}
+
+// readIdentifier reads the identifier starting at the specified index without advancing
+func readIdentifier(data *string, index int) string {
+	end := index
+	for end < len(*data) {
+		character, size := readRune(data, end)
+		if !isIdentifierCharacter(character) || end == index && !isIdentifierStart(character) {
+			break
+		}
+		end += size
+	}
+	return (*data)[index:end]
+}
+
+// readRune decodes the utf8 character at the specified index
+//
+// **Returns**
+//   the decoded character and the number of bytes it occupies in data
+func readRune(data *string, index int) (rune, int) {
+	return utf8.DecodeRuneInString((*data)[index:])
+}
+
 func peek(data *string, index int) byte {
 	for index < len(*data) && isWhiteSpace((*data)[index]) {
 		index++
@@ -21,17 +105,66 @@ func peek(data *string, index int) byte {
 	return 0
 }
 
-func parseSpecialCharacter(character byte) byte {
+// parseEscapeSequence parses the escape sequence following a backslash
+//
+// **Parameters**
+//   data:  script data
+//   index: index of the first character after the backslash, is moved behind the sequence
+func parseEscapeSequence(data *string, index *int) (rune, error) {
+	start := *index - 1
+	if *index >= len(*data) {
+		return 0, newParseError(start, "Escape sequence not terminated")
+	}
+
+	character, size := readRune(data, *index)
+	*index += size
+
 	switch character {
 	case 't':
-		return '\t'
+		return '\t', nil
 	case 'n':
-		return '\n'
+		return '\n', nil
 	case 'r':
-		return '\r'
+		return '\r', nil
+	case '0':
+		return 0, nil
+	case 'a':
+		return '\a', nil
+	case 'b':
+		return '\b', nil
+	case 'f':
+		return '\f', nil
+	case 'v':
+		return '\v', nil
+	case '\\', '\'', '"':
+		return character, nil
+	case 'x':
+		return parseHexCharacter(data, index, start, 2)
+	case 'u':
+		return parseHexCharacter(data, index, start, 4)
+	case 'U':
+		return parseHexCharacter(data, index, start, 8)
 	default:
-		return character
+		return 0, newParseError(start, "Unknown escape sequence '\\%c'", character)
+	}
+}
+
+func parseHexCharacter(data *string, index *int, start int, digits int) (rune, error) {
+	if *index+digits > len(*data) {
+		return 0, newParseError(start, "Escape sequence expects %d hex digits", digits)
 	}
+
+	code, err := strconv.ParseUint((*data)[*index:*index+digits], 16, 32)
+	if err != nil {
+		return 0, newParseError(start, "Escape sequence expects %d hex digits", digits)
+	}
+
+	if !utf8.ValidRune(rune(code)) {
+		return 0, newParseError(start, "Escape sequence '%s' is no valid character", (*data)[start:*index+digits])
+	}
+
+	*index += digits
+	return rune(code), nil
 }
 
 func skipWhiteSpaces(data *string, index *int) {
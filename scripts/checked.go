@@ -0,0 +1,37 @@
+package scripts
+
+import "fmt"
+
+// OverflowError error raised by integer arithmetic in a checked context when the result
+// doesn't fit into the type of the operation
+type OverflowError struct {
+	Operator OperatorType
+	LHS      interface{}
+	RHS      interface{}
+}
+
+// Error returns the error message
+func (err *OverflowError) Error() string {
+	if err.RHS == nil {
+		return fmt.Sprintf("Arithmetic operation '%v' on '%v' resulted in an overflow", err.Operator, err.LHS)
+	}
+	return fmt.Sprintf("Arithmetic operation '%v' on '%v' and '%v' resulted in an overflow", err.Operator, err.LHS, err.RHS)
+}
+
+// Checked executes a token in a checked or unchecked arithmetic context
+//
+// This overrides CheckedArithmetic of the execution options for the wrapped token.
+type Checked struct {
+	Enabled bool
+	Data    Token
+}
+
+// Execute executes the wrapped token
+func (checked *Checked) Execute(variables *Variables) (interface{}, error) {
+	options := variables.GetOptions()
+	options.CheckedArithmetic = checked.Enabled
+
+	context := NewVariables(variables)
+	context.SetOptions(options)
+	return checked.Data.Execute(context)
+}
@@ -0,0 +1,94 @@
+package scripts
+
+import "time"
+
+// Comparable can be implemented by host types to support the relational operators
+// <, <=, > and >=
+type Comparable interface {
+
+	// CompareTo compares this value to another value
+	//
+	// **Returns**
+	//   a negative number if this value is less than other, 0 if both are equal and a
+	//   positive number if this value is greater than other
+	CompareTo(other interface{}) (int, error)
+}
+
+// Equatable can be implemented by host types to support the equality operators == and !=
+type Equatable interface {
+
+	// Equals determines whether this value is equal to another value
+	Equals(other interface{}) (bool, error)
+}
+
+// compareHostValues compares values using Comparable or the builtin support for time values
+//
+// **Returns**
+//   comparision result, whether the values could be compared and any error
+func compareHostValues(lhs interface{}, rhs interface{}) (int, bool, error) {
+	if comparable, ok := lhs.(Comparable); ok {
+		comparision, err := comparable.CompareTo(rhs)
+		return comparision, true, err
+	}
+
+	if comparable, ok := rhs.(Comparable); ok {
+		comparision, err := comparable.CompareTo(lhs)
+		return -comparision, true, err
+	}
+
+	switch lhsvalue := lhs.(type) {
+	case time.Time:
+		if rhsvalue, ok := rhs.(time.Time); ok {
+			switch {
+			case lhsvalue.Before(rhsvalue):
+				return -1, true, nil
+			case lhsvalue.After(rhsvalue):
+				return 1, true, nil
+			default:
+				return 0, true, nil
+			}
+		}
+	case time.Duration:
+		if rhsvalue, ok := rhs.(time.Duration); ok {
+			switch {
+			case lhsvalue < rhsvalue:
+				return -1, true, nil
+			case lhsvalue > rhsvalue:
+				return 1, true, nil
+			default:
+				return 0, true, nil
+			}
+		}
+	}
+
+	return 0, false, nil
+}
+
+// equalHostValues compares values using Equatable or the builtin support for time values
+//
+// **Returns**
+//   whether the values are equal, whether the values could be compared and any error
+func equalHostValues(lhs interface{}, rhs interface{}) (bool, bool, error) {
+	if equatable, ok := lhs.(Equatable); ok {
+		equal, err := equatable.Equals(rhs)
+		return equal, true, err
+	}
+
+	if equatable, ok := rhs.(Equatable); ok {
+		equal, err := equatable.Equals(lhs)
+		return equal, true, err
+	}
+
+	switch lhsvalue := lhs.(type) {
+	case time.Time:
+		if rhsvalue, ok := rhs.(time.Time); ok {
+			return lhsvalue.Equal(rhsvalue), true, nil
+		}
+	case time.Duration:
+		if rhsvalue, ok := rhs.(time.Duration); ok {
+			return lhsvalue == rhsvalue, true, nil
+		}
+	}
+
+	return false, false, nil
+}
@@ -0,0 +1,141 @@
+package scripts
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// Converter converts a value to a target type
+type Converter func(value interface{}) (interface{}, error)
+
+type conversion struct {
+	name    string
+	target  reflect.Type
+	convert Converter
+}
+
+// Conversions registry of target types values can be converted to
+//
+// Every registered type can be used as cast in scripts like uuid(value). The go type of
+// a conversion is used to convert arguments passed to host functions expecting a
+// parameter of that type.
+type Conversions struct {
+	names map[string]*conversion
+	types map[reflect.Type]*conversion
+	enums map[string]*Enum
+}
+
+// castTargets builtin cast target types
+var castTargets = map[string]bool{
+	CAST_BOOL: true, CAST_SBYTE: true, CAST_BYTE: true, CAST_SHORT: true, CAST_USHORT: true, CAST_INT: true,
+	CAST_UINT: true, CAST_LONG: true, CAST_ULONG: true, CAST_CHAR: true, CAST_FLOAT: true, CAST_DOUBLE: true,
+	CAST_DECIMAL: true, CAST_BIGINT: true, CAST_STRING: true, CAST_DATETIME: true, CAST_TIMESPAN: true, CAST_ARRAY: true,
+}
+
+// castTypes builtin cast target types used to convert arguments of host functions
+var castTypes = map[reflect.Type]string{
+	reflect.TypeOf(false):            CAST_BOOL,
+	reflect.TypeOf(int8(0)):          CAST_SBYTE,
+	reflect.TypeOf(uint8(0)):         CAST_BYTE,
+	reflect.TypeOf(int16(0)):         CAST_SHORT,
+	reflect.TypeOf(uint16(0)):        CAST_USHORT,
+	reflect.TypeOf(int32(0)):         CAST_INT,
+	reflect.TypeOf(uint32(0)):        CAST_UINT,
+	reflect.TypeOf(int64(0)):         CAST_LONG,
+	reflect.TypeOf(uint64(0)):        CAST_ULONG,
+	reflect.TypeOf(0):                CAST_LONG,
+	reflect.TypeOf(uint(0)):          CAST_ULONG,
+	reflect.TypeOf(float32(0)):       CAST_FLOAT,
+	reflect.TypeOf(float64(0)):       CAST_DOUBLE,
+	reflect.TypeOf(Decimal{}):        CAST_DECIMAL,
+	reflect.TypeOf(&big.Int{}):       CAST_BIGINT,
+	reflect.TypeOf(Char(0)):          CAST_CHAR,
+	reflect.TypeOf(""):               CAST_STRING,
+	reflect.TypeOf(time.Time{}):      CAST_DATETIME,
+	reflect.TypeOf(time.Duration(0)): CAST_TIMESPAN,
+	reflect.TypeOf([]interface{}{}):  CAST_ARRAY,
+}
+
+// NewConversions creates a new conversion registry
+func NewConversions() *Conversions {
+	return &Conversions{
+		names: make(map[string]*conversion),
+		types: make(map[reflect.Type]*conversion),
+		enums: make(map[string]*Enum)}
+}
+
+// Register registers a conversion to a named target type
+//
+// **Parameters**
+//   name:      name of the target type used in scripts
+//   target:    go type of converted values, nil if the conversion is only used for casts
+//   converter: function converting values to the target type
+func (conversions *Conversions) Register(name string, target reflect.Type, converter Converter) error {
+	if castTargets[name] {
+		return fmt.Errorf("'%s' is a builtin cast and can't be registered", name)
+	}
+
+	registered := &conversion{name: name, target: target, convert: converter}
+	conversions.names[name] = registered
+	delete(conversions.enums, name)
+	if target != nil {
+		conversions.types[target] = registered
+	}
+	return nil
+}
+
+// IsTargetType determines whether a value can be cast to a type with the specified name
+func (conversions *Conversions) IsTargetType(name string) bool {
+	if castTargets[name] {
+		return true
+	}
+
+	if conversions == nil {
+		return false
+	}
+	_, ok := conversions.names[name]
+	return ok
+}
+
+// Convert converts a value to the type with the specified name
+//
+// Values of registered enums are converted to strings using their names.
+func (conversions *Conversions) Convert(value interface{}, targettype string) (interface{}, error) {
+	if conversions != nil {
+		if registered, ok := conversions.names[targettype]; ok {
+			return registered.convert(value)
+		}
+	}
+
+	if targettype == CAST_STRING {
+		if enum := conversions.enumOf(value); enum != nil {
+			if name, ok := enum.name(value); ok {
+				return name, nil
+			}
+		}
+	}
+
+	return castValue(value, targettype)
+}
+
+// convertTo converts a value to a go type
+//
+// **Returns**
+//   the converted value and whether a conversion to the type exists
+func (conversions *Conversions) convertTo(value interface{}, target reflect.Type) (interface{}, bool, error) {
+	if conversions != nil {
+		if registered, ok := conversions.types[target]; ok {
+			converted, err := registered.convert(value)
+			return converted, true, err
+		}
+	}
+
+	if name, ok := castTypes[target]; ok {
+		converted, err := castValue(value, name)
+		return converted, true, err
+	}
+
+	return nil, false, nil
+}
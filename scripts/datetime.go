@@ -0,0 +1,322 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateTimeLayouts layouts accepted for date literals and datetime casts
+var dateTimeLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// durationUnits units of a duration literal
+var durationUnits = map[string]time.Duration{
+	"d":  24 * time.Hour,
+	"h":  time.Hour,
+	"m":  time.Minute,
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+// parseDateTime parses a date in ISO 8601 format like '2020-01-31' or '2020-01-31 12:30:00'
+//
+// Dates without time zone are interpreted as UTC.
+func parseDateTime(text string) (time.Time, error) {
+	value := strings.TrimSpace(text)
+	for _, layout := range dateTimeLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("'%s' is not a valid date", text)
+}
+
+// parseDateLiteral parses a date literal like #2020-01-31# with index pointing behind the opening #
+func parseDateLiteral(data *string, index *int) (Token, error) {
+	start := *index
+	end := strings.IndexByte((*data)[start:], '#')
+	if end < 0 {
+		return nil, newParseError(start-1, "Date literal not terminated")
+	}
+
+	date, err := parseDateTime((*data)[start : start+end])
+	if err != nil {
+		return nil, newParseError(start, "%s", err.Error())
+	}
+
+	*index = start + end + 1
+	return &Value{Value: date}, nil
+}
+
+// parseDuration parses a duration literal consisting of numbers followed by a unit like '2h30m' or '1.5d'
+func parseDuration(token string) (time.Duration, error) {
+	value := strings.TrimSpace(token)
+	negative := strings.HasPrefix(value, "-")
+	if negative {
+		value = value[1:]
+	}
+
+	if len(value) == 0 {
+		return 0, fmt.Errorf("'%s' is not a valid duration", token)
+	}
+
+	var duration float64
+	for len(value) > 0 {
+		number := 0
+		for number < len(value) && (value[number] >= '0' && value[number] <= '9' || value[number] == '.') {
+			number++
+		}
+		unit := number
+		for unit < len(value) && !(value[unit] >= '0' && value[unit] <= '9' || value[unit] == '.') {
+			unit++
+		}
+
+		amount, err := strconv.ParseFloat(value[:number], 64)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a valid duration", token)
+		}
+		factor, ok := durationUnits[value[number:unit]]
+		if !ok {
+			return 0, fmt.Errorf("'%s' is not a valid duration", token)
+		}
+
+		duration += amount * float64(factor)
+		value = value[unit:]
+	}
+
+	if duration > math.MaxInt64 {
+		return 0, fmt.Errorf("Duration '%s' out of range", token)
+	}
+
+	if negative {
+		return -time.Duration(duration), nil
+	}
+	return time.Duration(duration), nil
+}
+
+// isDurationLiteral determines whether a number literal represents a duration
+//
+// A single component with the unit d, s or us is the decimal, short or ushort
+// suffix of a number, so '7d' is a decimal while '7d0h', '168h' or '1m30s' are durations.
+func isDurationLiteral(token string) bool {
+	components := 0
+	ambiguous := false
+	for index := 0; index < len(token); {
+		number := index
+		for number < len(token) && (token[number] >= '0' && token[number] <= '9' || token[number] == '.') {
+			number++
+		}
+		unit := number
+		for unit < len(token) && !(token[unit] >= '0' && token[unit] <= '9' || token[unit] == '.') {
+			unit++
+		}
+
+		if number == index {
+			return false
+		}
+		if _, ok := durationUnits[token[number:unit]]; !ok {
+			return false
+		}
+
+		switch token[number:unit] {
+		case "d", "s", "us":
+			ambiguous = true
+		}
+		components++
+		index = unit
+	}
+
+	return components > 1 || components == 1 && !ambiguous
+}
+
+// parseTimeSpan parses a duration in literal format like '2h30m' or in the format
+// [-][d.]hh:mm[:ss[.fffffff]] used by C#
+func parseTimeSpan(text string) (time.Duration, error) {
+	value := strings.TrimSpace(text)
+	if !strings.ContainsRune(value, ':') {
+		return parseDuration(value)
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	if negative {
+		value = value[1:]
+	}
+
+	var duration time.Duration
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("'%s' is not a valid timespan", text)
+	}
+
+	if dot := strings.IndexByte(parts[0], '.'); dot >= 0 {
+		days, err := strconv.Atoi(parts[0][:dot])
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a valid timespan", text)
+		}
+		duration += time.Duration(days) * 24 * time.Hour
+		parts[0] = parts[0][dot+1:]
+	}
+
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, part := range parts {
+		amount, err := strconv.ParseFloat(part, 64)
+		if err != nil || amount < 0 || i < len(parts)-1 && strings.ContainsRune(part, '.') {
+			return 0, fmt.Errorf("'%s' is not a valid timespan", text)
+		}
+		duration += time.Duration(amount * float64(units[i]))
+	}
+
+	if negative {
+		return -duration, nil
+	}
+	return duration, nil
+}
+
+// toDateTime converts a value to a date
+func toDateTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return parseDateTime(v)
+	default:
+		return time.Time{}, fmt.Errorf("Unable to convert '%v' to datetime", value)
+	}
+}
+
+// toTimeSpan converts a value to a duration
+//
+// Numbers are interpreted as milliseconds.
+func toTimeSpan(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		return parseTimeSpan(v)
+	}
+
+	switch numericTypeOf(value) {
+	case numericNone:
+		return 0, fmt.Errorf("Unable to convert '%v' to timespan", value)
+	case numericFloat, numericDouble, numericDecimal:
+		milliseconds := toFloat64(value) * float64(time.Millisecond)
+		if math.IsNaN(milliseconds) || math.Abs(milliseconds) > math.MaxInt64 {
+			return 0, fmt.Errorf("Unable to convert '%v' to timespan", value)
+		}
+		return time.Duration(milliseconds), nil
+	default:
+		milliseconds, _ := toBigInteger(value)
+		nanoseconds := new(big.Int).Mul(milliseconds, big.NewInt(int64(time.Millisecond)))
+		if !nanoseconds.IsInt64() {
+			return 0, fmt.Errorf("Unable to convert '%v' to timespan", value)
+		}
+		return time.Duration(nanoseconds.Int64()), nil
+	}
+}
+
+// timeArithmetic evaluates arithmetic operators on dates and durations
+//
+// Like in C# the difference of two dates is a duration, durations can be added to or
+// subtracted from dates and durations can be scaled by numbers.
+//
+// **Returns**
+//   the result, whether one of the operands was a date or duration and any error
+func (op *Operator) timeArithmetic(lhs interface{}, rhs interface{}) (interface{}, bool, error) {
+	_, lhsisstring := lhs.(string)
+	_, rhsisstring := rhs.(string)
+	if lhsisstring || rhsisstring {
+		return nil, false, nil
+	}
+
+	switch lhsvalue := lhs.(type) {
+	case time.Time:
+		switch rhsvalue := rhs.(type) {
+		case time.Time:
+			if op.Type == OP_Sub {
+				return lhsvalue.Sub(rhsvalue), true, nil
+			}
+		case time.Duration:
+			switch op.Type {
+			case OP_Add:
+				return lhsvalue.Add(rhsvalue), true, nil
+			case OP_Sub:
+				return lhsvalue.Add(-rhsvalue), true, nil
+			}
+		}
+	case time.Duration:
+		switch rhsvalue := rhs.(type) {
+		case time.Time:
+			if op.Type == OP_Add {
+				return rhsvalue.Add(lhsvalue), true, nil
+			}
+		case time.Duration:
+			switch op.Type {
+			case OP_Add:
+				return lhsvalue + rhsvalue, true, nil
+			case OP_Sub:
+				return lhsvalue - rhsvalue, true, nil
+			case OP_Div:
+				if rhsvalue == 0 {
+					return nil, true, errors.New("Division by zero")
+				}
+				return float64(lhsvalue) / float64(rhsvalue), true, nil
+			case OP_Mod:
+				if rhsvalue == 0 {
+					return nil, true, errors.New("Division by zero")
+				}
+				return lhsvalue % rhsvalue, true, nil
+			}
+		default:
+			if numericTypeOf(rhs) != numericNone && (op.Type == OP_Mul || op.Type == OP_Div) {
+				factor := toFloat64(rhs)
+				if op.Type == OP_Div {
+					if factor == 0 {
+						return nil, true, errors.New("Division by zero")
+					}
+					factor = 1 / factor
+				}
+				result, err := scaleDuration(lhsvalue, factor)
+				return result, true, err
+			}
+		}
+	default:
+		switch rhsvalue := rhs.(type) {
+		case time.Duration:
+			if numericTypeOf(lhs) != numericNone && op.Type == OP_Mul {
+				result, err := scaleDuration(rhsvalue, toFloat64(lhs))
+				return result, true, err
+			}
+		case time.Time:
+		default:
+			return nil, false, nil
+		}
+	}
+
+	return nil, true, fmt.Errorf("Operator '%v' not supported for '%v' and '%v'", op.Type, lhs, rhs)
+}
+
+// scaleDuration multiplies a duration by a factor rounding to whole nanoseconds
+func scaleDuration(duration time.Duration, factor float64) (time.Duration, error) {
+	result := math.Round(float64(duration) * factor)
+	if math.IsNaN(result) || math.Abs(result) > math.MaxInt64 {
+		return 0, fmt.Errorf("Duration '%v' multiplied by '%v' out of range", duration, factor)
+	}
+	return time.Duration(result), nil
+}
@@ -0,0 +1,270 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// DecimalDivisionPrecision number of fractional digits computed when dividing decimals
+// which don't divide exactly
+var DecimalDivisionPrecision = 28
+
+var bigTen = big.NewInt(10)
+
+// maxDecimalExponent maximum absolute exponent of parsed decimals which keeps the size of
+// the unscaled integers reasonable
+const maxDecimalExponent = 1000
+
+// Decimal arbitrary precision decimal number
+//
+// A decimal is stored as unscaled integer value and a scale defining the number of
+// fractional digits, so 12.50 is stored as 1250 with a scale of 2. Addition, subtraction
+// and multiplication are exact.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// NewDecimal creates a new decimal with the value unscaled * 10^-scale
+func NewDecimal(unscaled int64, scale int) Decimal {
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// ParseDecimal parses a decimal from its string representation
+//
+// Exponents like in '1.5e3' are limited to a range of -1000 to 1000.
+func ParseDecimal(text string) (Decimal, error) {
+	value := strings.TrimSpace(text)
+	if len(value) == 0 {
+		return Decimal{}, fmt.Errorf("'%s' is not a valid decimal", text)
+	}
+
+	exponent := 0
+	if e := strings.IndexAny(value, "eE"); e >= 0 {
+		parsed, err := strconv.Atoi(value[e+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("'%s' is not a valid decimal", text)
+		}
+		if parsed > maxDecimalExponent || parsed < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("Exponent of decimal '%s' is out of range", text)
+		}
+		exponent = parsed
+		value = value[:e]
+	}
+
+	scale := 0
+	if dot := strings.IndexByte(value, '.'); dot >= 0 {
+		scale = len(value) - dot - 1
+		value = value[:dot] + value[dot+1:]
+	}
+
+	unscaled, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("'%s' is not a valid decimal", text)
+	}
+
+	result := Decimal{unscaled: unscaled, scale: scale - exponent}
+	if result.scale < 0 {
+		result = result.rescale(0)
+	}
+	return result, nil
+}
+
+// NewDecimalFromFloat creates a decimal using the shortest representation of a float
+func NewDecimalFromFloat(value float64) (Decimal, error) {
+	return ParseDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// NewDecimalFromInt creates a decimal from an integer
+func NewDecimalFromInt(value *big.Int) Decimal {
+	return Decimal{unscaled: new(big.Int).Set(value)}
+}
+
+func (d Decimal) integer() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// rescale returns the decimal with the specified scale, truncating digits if necessary
+func (d Decimal) rescale(scale int) Decimal {
+	unscaled := new(big.Int).Set(d.integer())
+	if scale > d.scale {
+		unscaled.Mul(unscaled, pow10(scale-d.scale))
+	} else if scale < d.scale {
+		unscaled.Quo(unscaled, pow10(d.scale-scale))
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(exponent)), nil)
+}
+
+func alignDecimals(lhs Decimal, rhs Decimal) (Decimal, Decimal) {
+	if lhs.scale < rhs.scale {
+		return lhs.rescale(rhs.scale), rhs
+	}
+	if rhs.scale < lhs.scale {
+		return lhs, rhs.rescale(lhs.scale)
+	}
+	return lhs, rhs
+}
+
+// Add returns d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	lhs, rhs := alignDecimals(d, other)
+	return Decimal{unscaled: new(big.Int).Add(lhs.integer(), rhs.integer()), scale: lhs.scale}
+}
+
+// Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	lhs, rhs := alignDecimals(d, other)
+	return Decimal{unscaled: new(big.Int).Sub(lhs.integer(), rhs.integer()), scale: lhs.scale}
+}
+
+// Mul returns d * other
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.integer(), other.integer()), scale: d.scale + other.scale}
+}
+
+// Div returns d / other rounded to DecimalDivisionPrecision fractional digits
+func (d Decimal) Div(other Decimal) (Decimal, error) {
+	if other.Sign() == 0 {
+		return Decimal{}, errors.New("Division by zero")
+	}
+
+	// compute one additional digit which is used for rounding
+	precision := DecimalDivisionPrecision + 1
+	if d.scale-other.scale > precision {
+		precision = d.scale - other.scale
+	}
+
+	numerator := new(big.Int).Mul(d.integer(), pow10(precision+other.scale-d.scale))
+	quotient := Decimal{unscaled: numerator.Quo(numerator, other.integer()), scale: precision}
+	return quotient.Round(DecimalDivisionPrecision).normalize(), nil
+}
+
+// Mod returns the remainder of d / other with the sign of d
+func (d Decimal) Mod(other Decimal) (Decimal, error) {
+	if other.Sign() == 0 {
+		return Decimal{}, errors.New("Division by zero")
+	}
+
+	lhs, rhs := alignDecimals(d, other)
+	return Decimal{unscaled: new(big.Int).Rem(lhs.integer(), rhs.integer()), scale: lhs.scale}, nil
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.integer()), scale: d.scale}
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of the decimal
+func (d Decimal) Sign() int {
+	return d.integer().Sign()
+}
+
+// Cmp compares d to other
+//
+// **Returns**
+//   -1 if d < other, 0 if d == other, 1 if d > other
+func (d Decimal) Cmp(other Decimal) int {
+	lhs, rhs := alignDecimals(d, other)
+	return lhs.integer().Cmp(rhs.integer())
+}
+
+// Round rounds the decimal to the specified number of fractional digits with
+// midpoints rounded away from zero
+func (d Decimal) Round(places int) Decimal {
+	if places >= d.scale {
+		return d
+	}
+
+	factor := pow10(d.scale - places)
+	quotient, remainder := new(big.Int).QuoRem(d.integer(), factor, new(big.Int))
+	remainder.Abs(remainder).Mul(remainder, big.NewInt(2))
+	if remainder.Cmp(factor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(d.Sign())))
+	}
+
+	return Decimal{unscaled: quotient, scale: places}
+}
+
+// Truncate returns the integral part of the decimal
+func (d Decimal) Truncate() *big.Int {
+	return d.rescale(0).integer()
+}
+
+// normalize removes trailing zeros of the fractional part
+func (d Decimal) normalize() Decimal {
+	unscaled := new(big.Int).Set(d.integer())
+	scale := d.scale
+	remainder := new(big.Int)
+	for scale > 0 {
+		quotient, _ := new(big.Int).QuoRem(unscaled, bigTen, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+		unscaled = quotient
+		scale--
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// Float64 returns the nearest float value of the decimal
+func (d Decimal) Float64() float64 {
+	value, _ := strconv.ParseFloat(d.String(), 64)
+	return value
+}
+
+// String returns the decimal representation of the number
+func (d Decimal) String() string {
+	text := new(big.Int).Abs(d.integer()).String()
+	if d.scale > 0 {
+		if len(text) <= d.scale {
+			text = strings.Repeat("0", d.scale-len(text)+1) + text
+		}
+		text = text[:len(text)-d.scale] + "." + text[len(text)-d.scale:]
+	}
+
+	if d.Sign() < 0 {
+		return "-" + text
+	}
+	return text
+}
+
+// toDecimal converts a numeric value or a string to a decimal
+func toDecimal(value interface{}) (Decimal, error) {
+	switch v := value.(type) {
+	case Decimal:
+		return v, nil
+	case *big.Int:
+		return NewDecimalFromInt(v), nil
+	case Char:
+		return NewDecimal(int64(v), 0), nil
+	case int, int8, int16, int32, int64:
+		return NewDecimal(cast.ToInt64(v), 0), nil
+	case uint, uint8, uint16, uint32, uint64:
+		return NewDecimalFromInt(new(big.Int).SetUint64(cast.ToUint64(v))), nil
+	case float32:
+		return ParseDecimal(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	case float64:
+		return NewDecimalFromFloat(v)
+	case bool:
+		if v {
+			return NewDecimal(1, 0), nil
+		}
+		return NewDecimal(0, 0), nil
+	case string:
+		return ParseDecimal(v)
+	default:
+		return Decimal{}, fmt.Errorf("Unable to convert '%v' to decimal", value)
+	}
+}
@@ -0,0 +1,158 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Declaration declares a variable in the scope of the enclosing statement block
+//
+// Variables declared using var or let can be reassigned, constants declared using const
+// can not.
+type Declaration struct {
+	Name     string
+	Value    Token
+	Constant bool
+}
+
+// Execute declares the variable and returns its value
+func (declaration *Declaration) Execute(variables *Variables) (interface{}, error) {
+	var value interface{}
+	if declaration.Value != nil {
+		var err error
+		value, err = declaration.Value.Execute(variables)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := variables.declareVariable(declaration.Name, value, declaration.Constant); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// assign evaluates the assignment operator =
+func (op *Operator) assign(variables *Variables) (interface{}, error) {
+	variable, ok := op.LHS.(*Variable)
+	if !ok {
+		return nil, errors.New("Only variables can be assigned")
+	}
+
+	value, err := op.RHS.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := variables.assignVariable(variable.Name, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// DestructuringTarget variable declared by a destructuring declaration
+type DestructuringTarget struct {
+	Name    string
+	Default Token
+}
+
+// Destructuring declares variables for elements of an array or members of a value
+//
+//   var [first, second = 0] = list
+//   var { name, age = 0 } = person
+//
+// Array patterns take the elements of slices and arrays in order, object patterns take
+// map entries with the name of the variable or members of hosts. Defaults are used if
+// an element or member doesn't exist or is null.
+type Destructuring struct {
+	Targets  []*DestructuringTarget
+	Value    Token
+	Array    bool
+	Constant bool
+}
+
+// Execute declares the variables and returns the destructured value
+func (destructuring *Destructuring) Execute(variables *Variables) (interface{}, error) {
+	value, err := destructuring.Value.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, errors.New("Null reference")
+	}
+
+	for i, target := range destructuring.Targets {
+		var element interface{}
+		var found bool
+		if destructuring.Array {
+			element, found, err = arrayElement(value, i)
+		} else {
+			element, found, err = objectMember(value, target.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if element == nil && target.Default != nil {
+			element, err = target.Default.Execute(variables)
+			if err != nil {
+				return nil, err
+			}
+		} else if !found {
+			return nil, fmt.Errorf("No value for '%s' found in '%v'", target.Name, value)
+		}
+
+		if err := variables.declareVariable(target.Name, element, destructuring.Constant); err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// arrayElement returns the element of an array
+//
+// **Returns**
+//   the element, whether the array contains an element at the index and any error
+func arrayElement(value interface{}, index int) (interface{}, bool, error) {
+	array := reflect.ValueOf(value)
+	if array.Kind() != reflect.Slice && array.Kind() != reflect.Array {
+		return nil, false, fmt.Errorf("'%v' is not an array", value)
+	}
+
+	if index >= array.Len() {
+		return nil, false, nil
+	}
+	return array.Index(index).Interface(), true, nil
+}
+
+// objectMember returns the entry of a map or the field of a host value
+//
+// **Returns**
+//   the member value, whether the member exists and any error
+func objectMember(value interface{}, name string) (interface{}, bool, error) {
+	if host, ok := value.(MemberHost); ok {
+		member, found := host.Member(name)
+		return member, found, nil
+	}
+
+	object := reflect.ValueOf(value)
+	if object.Kind() == reflect.Map {
+		if object.Type().Key().Kind() != reflect.String {
+			return nil, false, fmt.Errorf("'%v' has no string keys", value)
+		}
+
+		entry := object.MapIndex(reflect.ValueOf(name).Convert(object.Type().Key()))
+		if !entry.IsValid() {
+			return nil, false, nil
+		}
+		return entry.Interface(), true, nil
+	}
+
+	field, err := memberField(value, name)
+	if err != nil || !field.IsValid() {
+		return nil, false, err
+	}
+	return field.Interface(), true, nil
+}
@@ -0,0 +1,147 @@
+package scripts
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Enum named values of a go integer or string type registered by the host
+//
+// Scripts access values like members of the enum, eg. Status.Active. Casting an enum value
+// to string results in its name, casting a name or an underlying value to the enum type
+// results in the enum value.
+type Enum struct {
+	Name     string
+	enumtype reflect.Type
+	values   map[string]interface{}
+	names    map[string]string
+}
+
+// Member returns the enum value with the specified name
+func (enum *Enum) Member(name string) (interface{}, bool) {
+	value, ok := enum.values[name]
+	return value, ok
+}
+
+// String returns the name of the enum
+func (enum *Enum) String() string {
+	return enum.Name
+}
+
+// RegisterEnum registers a named go integer or string type with its named values
+//
+// The enum can be used as cast target type like other registered conversions.
+//
+// **Parameters**
+//   name:   name of the enum used in scripts
+//   values: enum values by name which all have to be of the same type
+func (conversions *Conversions) RegisterEnum(name string, values map[string]interface{}) error {
+	enum := &Enum{
+		Name:   name,
+		values: make(map[string]interface{}),
+		names:  make(map[string]string)}
+
+	valuenames := make([]string, 0, len(values))
+	for valuename := range values {
+		valuenames = append(valuenames, valuename)
+	}
+	sort.Strings(valuenames)
+
+	for _, valuename := range valuenames {
+		value := values[valuename]
+		if len(readIdentifier(&valuename, 0)) != len(valuename) || len(valuename) == 0 {
+			return fmt.Errorf("'%s' is no valid name for a value of enum '%s'", valuename, name)
+		}
+
+		valuetype := reflect.TypeOf(value)
+		if valuetype == nil || enum.enumtype != nil && valuetype != enum.enumtype {
+			return fmt.Errorf("Values of enum '%s' have to be of the same type", name)
+		}
+		key, ok := enumKey(reflect.ValueOf(value))
+		if !ok {
+			return fmt.Errorf("Values of enum '%s' have to be integers or strings", name)
+		}
+
+		enum.enumtype = valuetype
+		enum.values[valuename] = value
+		enum.names[key] = valuename
+	}
+
+	if enum.enumtype == nil {
+		return fmt.Errorf("Enum '%s' has no values", name)
+	}
+	// builtin types are used by every script and can't represent an enum
+	if enum.enumtype.PkgPath() == "" {
+		return fmt.Errorf("Values of enum '%s' have to be of a named type like 'type %s %s'", name, name, enum.enumtype.Kind())
+	}
+
+	if err := conversions.Register(name, enum.enumtype, enum.convert); err != nil {
+		return err
+	}
+	conversions.enums[name] = enum
+	return nil
+}
+
+// enumKey determines the key of an enum value which is its underlying value as string
+func enumKey(value reflect.Value) (string, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), true
+	case reflect.String:
+		return value.String(), true
+	default:
+		return "", false
+	}
+}
+
+// convert converts a name or an underlying value to a value of the enum
+func (enum *Enum) convert(value interface{}) (interface{}, error) {
+	if reflect.TypeOf(value) == enum.enumtype {
+		return value, nil
+	}
+
+	if name, ok := value.(string); ok {
+		if enumvalue, ok := enum.values[name]; ok {
+			return enumvalue, nil
+		}
+	}
+
+	key, ok := "", false
+	if isIntegralType(numericTypeOf(value)) {
+		integer, _ := toBigInteger(value)
+		key, ok = integer.String(), true
+	} else if text, isstring := value.(string); isstring && enum.enumtype.Kind() == reflect.String {
+		key, ok = text, true
+	}
+
+	if ok {
+		if name, ok := enum.names[key]; ok {
+			return enum.values[name], nil
+		}
+	}
+	return nil, fmt.Errorf("'%v' is no value of enum '%s'", value, enum.Name)
+}
+
+// name returns the name of an enum value
+func (enum *Enum) name(value interface{}) (string, bool) {
+	key, _ := enumKey(reflect.ValueOf(value))
+	name, ok := enum.names[key]
+	return name, ok
+}
+
+// enumOf returns the registered enum of a value or nil if the value is no enum value
+func (conversions *Conversions) enumOf(value interface{}) *Enum {
+	if conversions == nil || value == nil {
+		return nil
+	}
+
+	registered, ok := conversions.types[reflect.TypeOf(value)]
+	if !ok {
+		return nil
+	}
+	return conversions.enums[registered.name]
+}
@@ -0,0 +1,104 @@
+package scripts
+
+import (
+	"reflect"
+)
+
+// valuesEqual determines whether two script values are equal
+//
+// Host types implementing Equatable decide on their own, numbers are equal if they
+// represent the same value regardless of their type, slices, arrays, maps and structs are
+// compared element by element, pointers are equal if they reference the same object.
+func valuesEqual(lhs interface{}, rhs interface{}) (bool, error) {
+	if lhs == nil || rhs == nil {
+		return isNil(lhs) && isNil(rhs), nil
+	}
+
+	if equal, handled, err := equalHostValues(lhs, rhs); handled {
+		return equal, err
+	}
+
+	if numericTypeOf(lhs) != numericNone && numericTypeOf(rhs) != numericNone {
+		comparision, ordered, err := compareNumbers(lhs, rhs)
+		return err == nil && ordered && comparision == 0, nil
+	}
+
+	lhsvalue := reflect.ValueOf(lhs)
+	rhsvalue := reflect.ValueOf(rhs)
+	switch lhsvalue.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rhsvalue.Kind() != reflect.Slice && rhsvalue.Kind() != reflect.Array {
+			return false, nil
+		}
+		if lhsvalue.Len() != rhsvalue.Len() {
+			return false, nil
+		}
+		for i := 0; i < lhsvalue.Len(); i++ {
+			equal, err := valuesEqual(lhsvalue.Index(i).Interface(), rhsvalue.Index(i).Interface())
+			if err != nil || !equal {
+				return false, err
+			}
+		}
+		return true, nil
+	case reflect.Map:
+		if rhsvalue.Kind() != reflect.Map || lhsvalue.Len() != rhsvalue.Len() {
+			return false, nil
+		}
+		if lhsvalue.Type().Key() != rhsvalue.Type().Key() {
+			return false, nil
+		}
+		iterator := lhsvalue.MapRange()
+		for iterator.Next() {
+			other := rhsvalue.MapIndex(iterator.Key())
+			if !other.IsValid() {
+				return false, nil
+			}
+			equal, err := valuesEqual(iterator.Value().Interface(), other.Interface())
+			if err != nil || !equal {
+				return false, err
+			}
+		}
+		return true, nil
+	case reflect.Struct:
+		if lhsvalue.Type() != rhsvalue.Type() {
+			return false, nil
+		}
+		for i := 0; i < lhsvalue.NumField(); i++ {
+			if !lhsvalue.Field(i).CanInterface() {
+				// unexported state can only be compared as a whole
+				return reflect.DeepEqual(lhs, rhs), nil
+			}
+		}
+		for i := 0; i < lhsvalue.NumField(); i++ {
+			equal, err := valuesEqual(lhsvalue.Field(i).Interface(), rhsvalue.Field(i).Interface())
+			if err != nil || !equal {
+				return false, err
+			}
+		}
+		return true, nil
+	case reflect.Ptr, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return lhsvalue.Type() == rhsvalue.Type() && lhsvalue.Pointer() == rhsvalue.Pointer(), nil
+	default:
+		if lhsvalue.Type() != rhsvalue.Type() {
+			return false, nil
+		}
+		if !lhsvalue.Type().Comparable() {
+			return reflect.DeepEqual(lhs, rhs), nil
+		}
+		return lhs == rhs, nil
+	}
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return reflected.IsNil()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,486 @@
+package scripts
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cast"
+)
+
+// formatValue formats a value like a hole of an interpolated string
+//
+// **Parameters**
+//   value:     value to format
+//   alignment: minimum width of the result, positive values align right, negative values align left
+//   format:    standard or custom format string for numbers and dates
+func formatValue(value interface{}, alignment int, format string) (string, error) {
+	var text string
+	var err error
+
+	switch v := value.(type) {
+	case time.Time:
+		text, err = formatTime(v, format)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		text, err = formatNumber(v, format)
+	case Decimal:
+		text, err = formatDecimal(v, format)
+	case *big.Int:
+		text, err = formatBigInteger(v, format)
+	default:
+		if len(format) > 0 {
+			return "", fmt.Errorf("Format '%s' not supported for '%v'", format, value)
+		}
+		text = fmt.Sprintf("%v", value)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return alignText(text, alignment), nil
+}
+
+func alignText(text string, alignment int) string {
+	padding := alignment
+	if padding < 0 {
+		padding = -padding
+	}
+
+	padding -= utf8.RuneCountInString(text)
+	if padding <= 0 {
+		return text
+	}
+
+	if alignment < 0 {
+		return text + strings.Repeat(" ", padding)
+	}
+	return strings.Repeat(" ", padding) + text
+}
+
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseStandardFormat splits a standard format like 'F2' into specifier and precision
+//
+// **Returns**
+//   specifier, precision (-1 if not specified) and whether format is a standard format
+func parseStandardFormat(format string) (byte, int, bool) {
+	if len(format) == 0 {
+		return 0, -1, false
+	}
+
+	specifier := format[0]
+	if !(specifier >= 'a' && specifier <= 'z' || specifier >= 'A' && specifier <= 'Z') {
+		return 0, -1, false
+	}
+
+	if len(format) == 1 {
+		return specifier, -1, true
+	}
+
+	precision, err := strconv.Atoi(format[1:])
+	if err != nil || precision < 0 || precision > 99 {
+		return 0, -1, false
+	}
+
+	return specifier, precision, true
+}
+
+func formatNumber(value interface{}, format string) (string, error) {
+	if len(format) == 0 {
+		return fmt.Sprintf("%v", value), nil
+	}
+
+	specifier, precision, standard := parseStandardFormat(format)
+	if !standard || strings.IndexByte("FfNnPp", specifier) >= 0 {
+		return formatNumberAsDecimal(value, format)
+	}
+
+	switch specifier {
+	case 'E', 'e':
+		if precision < 0 {
+			precision = 6
+		}
+		return formatExponent(cast.ToFloat64(value), specifier, precision), nil
+	case 'D', 'd':
+		if !isInteger(value) {
+			return "", fmt.Errorf("Format '%s' is only supported for integers", format)
+		}
+		text := fmt.Sprintf("%d", value)
+		if strings.HasPrefix(text, "-") {
+			return "-" + padDigits(text[1:], precision), nil
+		}
+		return padDigits(text, precision), nil
+	case 'X', 'x':
+		if !isInteger(value) {
+			return "", fmt.Errorf("Format '%s' is only supported for integers", format)
+		}
+		text := strconv.FormatUint(twosComplement(value), 16)
+		if specifier == 'X' {
+			text = strings.ToUpper(text)
+		}
+		return padDigits(text, precision), nil
+	case 'G', 'g':
+		if precision < 0 {
+			return fmt.Sprintf("%v", value), nil
+		}
+		return strconv.FormatFloat(cast.ToFloat64(value), 'g', precision, 64), nil
+	default:
+		return "", fmt.Errorf("Unknown format specifier '%s'", format)
+	}
+}
+
+// formatNumberAsDecimal formats an integer or float like a decimal with the same value, so
+// digits of large integers aren't lost and midpoints are rounded away from zero
+func formatNumberAsDecimal(value interface{}, format string) (string, error) {
+	if isInteger(value) {
+		integer, err := toBigInteger(value)
+		if err != nil {
+			return "", err
+		}
+		return formatDecimal(NewDecimalFromInt(integer), format)
+	}
+
+	float := cast.ToFloat64(value)
+	switch {
+	case math.IsNaN(float):
+		return "NaN", nil
+	case math.IsInf(float, 1):
+		return "∞", nil
+	case math.IsInf(float, -1):
+		return "-∞", nil
+	}
+
+	bitsize := 64
+	if _, ok := value.(float32); ok {
+		bitsize = 32
+	}
+	decimal, err := ParseDecimal(strconv.FormatFloat(float, 'f', -1, bitsize))
+	if err != nil {
+		return "", err
+	}
+	return formatDecimal(decimal, format)
+}
+
+// formatDecimal formats a decimal without losing precision for fixed point and custom formats
+func formatDecimal(value Decimal, format string) (string, error) {
+	specifier, precision, standard := parseStandardFormat(format)
+	if precision < 0 {
+		precision = 2
+	}
+
+	switch {
+	case len(format) == 0:
+		return value.String(), nil
+	case standard && (specifier == 'F' || specifier == 'f'):
+		return value.Round(precision).rescale(precision).String(), nil
+	case standard && (specifier == 'N' || specifier == 'n'):
+		return groupDigits(value.Round(precision).rescale(precision).String()), nil
+	case standard && (specifier == 'P' || specifier == 'p'):
+		percent := value.Mul(NewDecimal(100, 0))
+		return groupDigits(percent.Round(precision).rescale(precision).String()) + " %", nil
+	case !standard:
+		return formatCustomNumber(value, format), nil
+	default:
+		return formatNumber(value.Float64(), format)
+	}
+}
+
+// formatBigInteger formats a big integer without losing precision for integral formats
+func formatBigInteger(value *big.Int, format string) (string, error) {
+	specifier, precision, standard := parseStandardFormat(format)
+	if !standard {
+		if len(format) == 0 {
+			return value.String(), nil
+		}
+		return formatDecimal(NewDecimalFromInt(value), format)
+	}
+
+	switch specifier {
+	case 'D', 'd':
+		text := new(big.Int).Abs(value).String()
+		if value.Sign() < 0 {
+			return "-" + padDigits(text, precision), nil
+		}
+		return padDigits(text, precision), nil
+	case 'X', 'x':
+		if value.Sign() < 0 {
+			return "", fmt.Errorf("Format '%s' is not supported for negative big integers", format)
+		}
+		text := value.Text(16)
+		if specifier == 'X' {
+			text = strings.ToUpper(text)
+		}
+		return padDigits(text, precision), nil
+	default:
+		return formatDecimal(NewDecimalFromInt(value), format)
+	}
+}
+
+func padDigits(text string, digits int) string {
+	if len(text) >= digits {
+		return text
+	}
+	return strings.Repeat("0", digits-len(text)) + text
+}
+
+// twosComplement returns the bits of an integer as unsigned value of the same width
+func twosComplement(value interface{}) uint64 {
+	switch v := value.(type) {
+	case int:
+		return uint64(v)
+	case int8:
+		return uint64(uint8(v))
+	case int16:
+		return uint64(uint16(v))
+	case int32:
+		return uint64(uint32(v))
+	case int64:
+		return uint64(v)
+	default:
+		return cast.ToUint64(v)
+	}
+}
+
+// groupDigits inserts thousand separators into the integral part of a formatted number
+func groupDigits(text string) string {
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign = "-"
+		text = text[1:]
+	}
+
+	integral := text
+	fraction := ""
+	if dot := strings.IndexByte(text, '.'); dot >= 0 {
+		integral = text[:dot]
+		fraction = text[dot:]
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(integral); i++ {
+		if i > 0 && (len(integral)-i)%3 == 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteByte(integral[i])
+	}
+
+	return sign + builder.String() + fraction
+}
+
+// formatExponent formats a number in scientific notation with an exponent of at least 3 digits
+//
+// Like in C# NaN and infinite values are formatted as 'NaN', '∞' and '-∞'.
+func formatExponent(value float64, specifier byte, precision int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "∞"
+	case math.IsInf(value, -1):
+		return "-∞"
+	}
+
+	text := strconv.FormatFloat(value, 'e', precision, 64)
+	mantissa := text
+	exponent := ""
+	if e := strings.IndexByte(text, 'e'); e >= 0 {
+		mantissa = text[:e]
+		exponent = text[e+1:]
+	}
+
+	sign := exponent[:1]
+	return fmt.Sprintf("%s%c%s%s", mantissa, specifier, sign, padDigits(exponent[1:], 3))
+}
+
+// formatCustomNumber formats a number using a custom pattern like '#,##0.00'
+func formatCustomNumber(value Decimal, format string) string {
+	first := strings.IndexAny(format, "0#")
+	if first < 0 {
+		return format
+	}
+	last := strings.LastIndexAny(format, "0#")
+
+	prefix := format[:first]
+	suffix := format[last+1:]
+	pattern := format[first : last+1]
+	if strings.Contains(prefix+suffix, "%") {
+		value = value.Mul(NewDecimal(100, 0))
+	}
+
+	integral := pattern
+	fraction := ""
+	if dot := strings.IndexByte(pattern, '.'); dot >= 0 {
+		integral = pattern[:dot]
+		fraction = pattern[dot+1:]
+	}
+
+	minintegral := strings.Count(integral, "0")
+	minfraction := strings.Count(fraction, "0")
+	maxfraction := minfraction + strings.Count(fraction, "#")
+
+	negative := value.Sign() < 0
+	if negative {
+		value = value.Neg()
+	}
+	text := value.Round(maxfraction).rescale(maxfraction).String()
+	if maxfraction > minfraction {
+		trim := len(text)
+		for i := 0; i < maxfraction-minfraction && text[trim-1] == '0'; i++ {
+			trim--
+		}
+		text = strings.TrimSuffix(text[:trim], ".")
+	}
+
+	digits := text
+	decimals := ""
+	if dot := strings.IndexByte(text, '.'); dot >= 0 {
+		digits = text[:dot]
+		decimals = text[dot:]
+	}
+
+	if digits == "0" && minintegral == 0 {
+		digits = ""
+	}
+	digits = padDigits(digits, minintegral)
+	text = digits + decimals
+	if strings.Contains(integral, ",") {
+		text = groupDigits(text)
+	}
+
+	if negative && strings.Trim(text, "0.,") != "" {
+		text = "-" + text
+	}
+
+	return prefix + text + suffix
+}
+
+func formatTime(value time.Time, format string) (string, error) {
+	switch format {
+	case "":
+		return fmt.Sprintf("%v", value), nil
+	case "d":
+		return value.Format("01/02/2006"), nil
+	case "D":
+		return value.Format("Monday, 02 January 2006"), nil
+	case "t":
+		return value.Format("15:04"), nil
+	case "T":
+		return value.Format("15:04:05"), nil
+	case "g":
+		return value.Format("01/02/2006 15:04"), nil
+	case "G":
+		return value.Format("01/02/2006 15:04:05"), nil
+	case "s":
+		return value.Format("2006-01-02T15:04:05"), nil
+	case "u":
+		return value.UTC().Format("2006-01-02 15:04:05Z"), nil
+	case "o", "O":
+		return value.Format(time.RFC3339Nano), nil
+	}
+
+	if len(format) == 1 {
+		return "", fmt.Errorf("Unknown date format specifier '%s'", format)
+	}
+
+	return formatCustomTime(value, format), nil
+}
+
+// formatCustomTime formats a time using a custom pattern like 'yyyy-MM-dd HH:mm'
+func formatCustomTime(value time.Time, format string) string {
+	var builder strings.Builder
+
+	for i := 0; i < len(format); {
+		character := format[i]
+		count := 1
+		for i+count < len(format) && format[i+count] == character {
+			count++
+		}
+
+		switch character {
+		case 'y':
+			if count <= 2 {
+				builder.WriteString(padDigits(strconv.Itoa(value.Year()%100), count))
+			} else {
+				builder.WriteString(padDigits(strconv.Itoa(value.Year()), count))
+			}
+		case 'M':
+			switch count {
+			case 1, 2:
+				builder.WriteString(padDigits(strconv.Itoa(int(value.Month())), count))
+			case 3:
+				builder.WriteString(value.Month().String()[:3])
+			default:
+				builder.WriteString(value.Month().String())
+			}
+		case 'd':
+			switch count {
+			case 1, 2:
+				builder.WriteString(padDigits(strconv.Itoa(value.Day()), count))
+			case 3:
+				builder.WriteString(value.Weekday().String()[:3])
+			default:
+				builder.WriteString(value.Weekday().String())
+			}
+		case 'H':
+			builder.WriteString(padDigits(strconv.Itoa(value.Hour()), count))
+		case 'h':
+			hour := value.Hour() % 12
+			if hour == 0 {
+				hour = 12
+			}
+			builder.WriteString(padDigits(strconv.Itoa(hour), count))
+		case 'm':
+			builder.WriteString(padDigits(strconv.Itoa(value.Minute()), count))
+		case 's':
+			builder.WriteString(padDigits(strconv.Itoa(value.Second()), count))
+		case 'f':
+			fraction := padDigits(strconv.Itoa(value.Nanosecond()), 9)
+			if count > 9 {
+				count = 9
+			}
+			builder.WriteString(fraction[:count])
+		case 't':
+			meridiem := "AM"
+			if value.Hour() >= 12 {
+				meridiem = "PM"
+			}
+			if count == 1 {
+				meridiem = meridiem[:1]
+			}
+			builder.WriteString(meridiem)
+		case '\'', '"':
+			end := strings.IndexByte(format[i+1:], character)
+			if end < 0 {
+				builder.WriteString(format[i+1:])
+				return builder.String()
+			}
+			builder.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		case '\\':
+			if i+1 < len(format) {
+				builder.WriteByte(format[i+1])
+			}
+			i += 2
+			continue
+		default:
+			builder.WriteString(format[i : i+count])
+		}
+
+		i += count
+	}
+
+	return builder.String()
+}
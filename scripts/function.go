@@ -0,0 +1,214 @@
+package scripts
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// builtinFunctions functions available to every script
+var builtinFunctions = map[string]interface{}{
+	"now": time.Now,
+	"today": func() time.Time {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	},
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Call calls a function
+//
+// Functions are go funcs provided as variable values by the host or functions declared by
+// scripts. If no variable with the name of the function exists a builtin function like
+// now() is called. Calls with a host like pricing.gross(100) call a member of the host.
+// Arguments of go funcs are converted to their parameter types using Conversions.
+type Call struct {
+	Name        string
+	Host        Token
+	Parameters  []Token
+	conversions *Conversions
+}
+
+// Execute calls the function with the values of the parameters
+func (call *Call) Execute(variables *Variables) (interface{}, error) {
+	function, err := call.function(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments, err := call.arguments(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return call.invoke(function, arguments)
+}
+
+// arguments evaluates the parameters of the call
+func (call *Call) arguments(variables *Variables) ([]interface{}, error) {
+	arguments := make([]interface{}, len(call.Parameters))
+	for i, parameter := range call.Parameters {
+		argument, err := parameter.Execute(variables)
+		if err != nil {
+			return nil, err
+		}
+		arguments[i] = argument
+	}
+	return arguments, nil
+}
+
+func (call *Call) function(variables *Variables) (interface{}, error) {
+	if call.Host != nil {
+		host, err := call.Host.Execute(variables)
+		if err != nil {
+			return nil, err
+		}
+		return memberValue(host, call.Name)
+	}
+
+	if variables != nil {
+		if function, err := variables.GetVariable(call.Name); err == nil {
+			return function, nil
+		}
+	}
+
+	if function, ok := builtinFunctions[call.Name]; ok {
+		return function, nil
+	}
+
+	return nil, fmt.Errorf("Function '%s' not found", call.Name)
+}
+
+// invoke calls a go func using reflection
+//
+// A func can return a single value, an error or a value and an error.
+func (call *Call) invoke(function interface{}, arguments []interface{}) (interface{}, error) {
+	if scriptfunction, ok := function.(*Function); ok {
+		return scriptfunction.call(arguments)
+	}
+
+	method := reflect.ValueOf(function)
+	if method.Kind() != reflect.Func {
+		return nil, fmt.Errorf("'%s' is not a function", call.Name)
+	}
+
+	methodtype := method.Type()
+	parametercount := methodtype.NumIn()
+	if methodtype.IsVariadic() && len(arguments) < parametercount-1 || !methodtype.IsVariadic() && len(arguments) != parametercount {
+		return nil, fmt.Errorf("Function '%s' expects %d parameters but %d were specified", call.Name, parametercount, len(arguments))
+	}
+
+	values := make([]reflect.Value, len(arguments))
+	for i, argument := range arguments {
+		var parametertype reflect.Type
+		if methodtype.IsVariadic() && i >= parametercount-1 {
+			parametertype = methodtype.In(parametercount - 1).Elem()
+		} else {
+			parametertype = methodtype.In(i)
+		}
+
+		value, err := call.conversions.convertArgument(argument, parametertype)
+		if err != nil {
+			return nil, fmt.Errorf("Parameter %d of function '%s': %v", i+1, call.Name, err)
+		}
+		values[i] = value
+	}
+
+	results := method.Call(values)
+	if len(results) > 0 && methodtype.Out(len(results)-1) == errorType {
+		if err := results[len(results)-1].Interface(); err != nil {
+			return nil, err.(error)
+		}
+		results = results[:len(results)-1]
+	}
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return results[0].Interface(), nil
+}
+
+// Function function declared by a script
+//
+// The result of a function is the result of the last statement of its body. Functions can
+// access the variables of the block they were declared in.
+type Function struct {
+	Name       string
+	Parameters []string
+	body       *StatementBlock
+	scope      *Variables
+}
+
+// call executes the function body with the arguments declared as variables
+func (function *Function) call(arguments []interface{}) (interface{}, error) {
+	if len(arguments) != len(function.Parameters) {
+		return nil, fmt.Errorf("Function '%s' expects %d parameters but %d were specified", function.Name, len(function.Parameters), len(arguments))
+	}
+
+	variables := NewVariables(function.scope)
+	for i, parameter := range function.Parameters {
+		if err := variables.declareVariable(parameter, arguments[i], false); err != nil {
+			return nil, err
+		}
+	}
+
+	return function.body.Execute(variables)
+}
+
+// FunctionDeclaration declares a function as constant in the scope of the enclosing block
+//
+//   function gross(net, rate) { net * (1 + rate) }
+type FunctionDeclaration struct {
+	Name       string
+	Parameters []string
+	Body       *StatementBlock
+}
+
+// Execute declares the function and returns it
+func (declaration *FunctionDeclaration) Execute(variables *Variables) (interface{}, error) {
+	function := &Function{
+		Name:       declaration.Name,
+		Parameters: declaration.Parameters,
+		body:       declaration.Body,
+		scope:      variables}
+
+	if err := variables.declareVariable(declaration.Name, function, true); err != nil {
+		return nil, err
+	}
+	return function, nil
+}
+
+// convertArgument converts a script value to the type of a function parameter
+func (conversions *Conversions) convertArgument(argument interface{}, parametertype reflect.Type) (reflect.Value, error) {
+	if argument == nil {
+		switch parametertype.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			return reflect.Zero(parametertype), nil
+		default:
+			return reflect.Value{}, fmt.Errorf("null can not be converted to '%v'", parametertype)
+		}
+	}
+
+	value := reflect.ValueOf(argument)
+	if value.Type().AssignableTo(parametertype) {
+		return value, nil
+	}
+
+	converted, ok, err := conversions.convertTo(argument, parametertype)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if ok && converted != nil {
+		value = reflect.ValueOf(converted)
+		if value.Type().AssignableTo(parametertype) {
+			return value, nil
+		}
+		// go int and uint are converted from long and ulong
+		if value.Type().ConvertibleTo(parametertype) {
+			return value.Convert(parametertype), nil
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("'%v' can not be converted to '%v'", argument, parametertype)
+}
@@ -10,6 +10,13 @@ type Interpolation struct {
 	tokens []Token
 }
 
+// Format formats the value of a token using alignment and format string
+type Format struct {
+	value     Token
+	alignment int
+	format    string
+}
+
 // Execute combines all tokens to a string
 func (ip *Interpolation) Execute(variables *Variables) (interface{}, error) {
 	var builder strings.Builder
@@ -24,3 +31,13 @@ func (ip *Interpolation) Execute(variables *Variables) (interface{}, error) {
 
 	return builder.String(), nil
 }
+
+// Execute formats the value of the token
+func (format *Format) Execute(variables *Variables) (interface{}, error) {
+	value, err := format.value.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return formatValue(value, format.alignment, format.format)
+}
@@ -0,0 +1,150 @@
+package scripts
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+var regexCache sync.Map
+
+// matchRegex determines whether the string representation of a value matches a regular expression
+func matchRegex(value interface{}, pattern interface{}) (bool, error) {
+	expression, ok := pattern.(string)
+	if !ok {
+		return false, fmt.Errorf("'%v' is not a valid regular expression", pattern)
+	}
+
+	var regex *regexp.Regexp
+	if cached, ok := regexCache.Load(expression); ok {
+		regex = cached.(*regexp.Regexp)
+	} else {
+		compiled, err := regexp.Compile(expression)
+		if err != nil {
+			return false, err
+		}
+		regexCache.Store(expression, compiled)
+		regex = compiled
+	}
+
+	return regex.MatchString(fmt.Sprintf("%v", value)), nil
+}
+
+// regexMatch evaluates the operators ~~ and !~ which match a value against a regular expression
+func (op *Operator) regexMatch(variables *Variables) (bool, error) {
+	lhs, rhs, err := op.operands(variables)
+	if err != nil {
+		return false, err
+	}
+
+	matches, err := matchRegex(lhs, rhs)
+	if err != nil {
+		return false, err
+	}
+
+	return matches == (op.Type == OP_Match), nil
+}
+
+// Pattern pattern of a match arm
+type Pattern interface {
+
+	// determines whether the value matches the pattern
+	Matches(value interface{}, variables *Variables) (bool, error)
+}
+
+// discardPattern pattern '_' matching every value
+type discardPattern struct{}
+
+func (pattern *discardPattern) Matches(value interface{}, variables *Variables) (bool, error) {
+	return true, nil
+}
+
+// typePattern pattern matching values of a type like 'int'
+type typePattern struct {
+	typename *TypeName
+}
+
+func (pattern *typePattern) Matches(value interface{}, variables *Variables) (bool, error) {
+	return pattern.typename.Matches(value), nil
+}
+
+// regexPattern pattern matching values against a regular expression like '~~ "^DEV-"'
+type regexPattern struct {
+	expression Token
+}
+
+func (pattern *regexPattern) Matches(value interface{}, variables *Variables) (bool, error) {
+	expression, err := pattern.expression.Execute(variables)
+	if err != nil {
+		return false, err
+	}
+
+	return matchRegex(value, expression)
+}
+
+// valuePattern pattern matching values equal to a constant or contained in a range
+type valuePattern struct {
+	expression Token
+}
+
+func (pattern *valuePattern) Matches(value interface{}, variables *Variables) (bool, error) {
+	expected, err := pattern.expression.Execute(variables)
+	if err != nil {
+		return false, err
+	}
+
+	if r, ok := expected.(Range); ok {
+		return r.Contains(value)
+	}
+
+	return valuesEqual(value, expected)
+}
+
+// MatchArm arm of a match expression
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Token
+	Result  Token
+}
+
+// Match evaluates to the result of the first arm whose pattern matches a value
+//
+//   match (value) { pattern when guard => result, ... }
+type Match struct {
+	Value Token
+	Arms  []*MatchArm
+}
+
+// Execute evaluates the match expression
+func (match *Match) Execute(variables *Variables) (interface{}, error) {
+	value, err := match.Value.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range match.Arms {
+		matches, err := arm.Pattern.Matches(value, variables)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches && arm.Guard != nil {
+			guard, err := arm.Guard.Execute(variables)
+			if err != nil {
+				return nil, err
+			}
+
+			condition, ok := guard.(bool)
+			if !ok {
+				return nil, fmt.Errorf("Guard has to evaluate to a boolean but evaluated to '%v'", guard)
+			}
+			matches = condition
+		}
+
+		if matches {
+			return arm.Result.Execute(variables)
+		}
+	}
+
+	return nil, fmt.Errorf("No pattern matches '%v'", value)
+}
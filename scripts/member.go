@@ -7,6 +7,13 @@ import (
 	"strings"
 )
 
+// MemberHost host value providing its members by name instead of struct fields
+type MemberHost interface {
+
+	// returns the value of a member and whether the member exists
+	Member(name string) (interface{}, bool)
+}
+
 // Member field of a host value
 type Member struct {
 	host   Token
@@ -20,19 +27,55 @@ func (member *Member) Execute(variables *Variables) (interface{}, error) {
 		return nil, err
 	}
 
+	return memberValue(hostvalue, member.member)
+}
+
+// memberValue returns the value of a field of a host value
+//
+// Field names are matched case insensitive, unexported fields are not accessible. Members
+// of a MemberHost are provided by the host.
+func memberValue(hostvalue interface{}, name string) (interface{}, error) {
+	if host, ok := hostvalue.(MemberHost); ok {
+		if value, ok := host.Member(name); ok {
+			return value, nil
+		}
+		return nil, fmt.Errorf("Member with name '%s' not found on '%v'", name, hostvalue)
+	}
+
+	field, err := memberField(hostvalue, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !field.IsValid() {
+		return nil, fmt.Errorf("Member with name '%s' not found on '%v'", name, hostvalue)
+	}
+
+	return field.Interface(), nil
+}
+
+// memberField finds a field of a host value
+//
+// **Returns**
+//   the field or an invalid value if the host has no field with the specified name
+func memberField(hostvalue interface{}, name string) (reflect.Value, error) {
 	if hostvalue == nil {
-		return nil, errors.New("Null reference")
+		return reflect.Value{}, errors.New("Null reference")
+	}
+
+	membername := strings.ToLower(name)
+	typevalue := reflect.Indirect(reflect.ValueOf(hostvalue))
+	if typevalue.Kind() != reflect.Struct {
+		return reflect.Value{}, nil
 	}
 
-	membername := strings.ToLower(member.member)
-	typevalue := reflect.ValueOf(hostvalue).Elem()
-	hosttype := reflect.TypeOf(hostvalue).Elem()
+	hosttype := typevalue.Type()
 	for i := 0; i < hosttype.NumField(); i++ {
 		field := hosttype.Field(i)
-		if strings.ToLower(field.Name) == membername {
-			return typevalue.Field(i).Interface(), nil
+		if field.PkgPath == "" && strings.ToLower(field.Name) == membername {
+			return typevalue.Field(i), nil
 		}
 	}
 
-	return nil, fmt.Errorf("Member with name '%s' not found on '%v'", member.member, hostvalue)
+	return reflect.Value{}, nil
 }
@@ -0,0 +1,204 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ModuleLoader loads the source of modules imported by scripts
+type ModuleLoader interface {
+
+	// loads the script code of the module with the specified path
+	Load(path string) (string, error)
+}
+
+// FileLoader loads modules from files below a root directory
+type FileLoader struct {
+	root string
+}
+
+// NewFileLoader creates a new loader for modules stored below a root directory
+func NewFileLoader(root string) *FileLoader {
+	return &FileLoader{root: root}
+}
+
+// Load reads the module file
+//
+// Paths are relative to the root directory and separated by '/'. Paths leaving the root
+// directory or containing backslashes or volume names like 'C:' are rejected on every
+// platform.
+func (loader *FileLoader) Load(modulepath string) (string, error) {
+	cleaned := path.Clean("/" + modulepath)
+	if cleaned != "/"+modulepath || strings.ContainsAny(modulepath, "\\:") {
+		return "", fmt.Errorf("Invalid module path '%s'", modulepath)
+	}
+
+	root := filepath.Clean(loader.root)
+	file := filepath.Join(root, filepath.FromSlash(cleaned))
+	if relative, err := filepath.Rel(root, file); err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Invalid module path '%s'", modulepath)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// moduleCache parsed modules shared by a parser and the parsers of modules it imports
+type moduleCache struct {
+	loader  ModuleLoader
+	modules map[string]*StatementBlock
+	lock    sync.Mutex
+}
+
+func (cache *moduleCache) get(path string) *StatementBlock {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return cache.modules[path]
+}
+
+func (cache *moduleCache) add(path string, module *StatementBlock) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.modules[path] = module
+}
+
+// Module members exported by an imported module
+type Module struct {
+	Path    string
+	members map[string]interface{}
+}
+
+// Member returns an exported function or variable of the module
+func (module *Module) Member(name string) (interface{}, bool) {
+	value, ok := module.members[name]
+	return value, ok
+}
+
+// String returns a description of the module
+func (module *Module) String() string {
+	return fmt.Sprintf("module '%s'", module.Path)
+}
+
+// Export marks a declaration of a module to be visible to scripts importing the module
+type Export struct {
+	Declaration Token
+}
+
+// Execute executes the exported declaration
+func (export *Export) Execute(variables *Variables) (interface{}, error) {
+	return export.Declaration.Execute(variables)
+}
+
+// names returns the names of the variables declared by the exported declaration
+func (export *Export) names() []string {
+	switch declaration := export.Declaration.(type) {
+	case *Declaration:
+		return []string{declaration.Name}
+	case *FunctionDeclaration:
+		return []string{declaration.Name}
+	case *Destructuring:
+		names := make([]string, len(declaration.Targets))
+		for i, target := range declaration.Targets {
+			names[i] = target.Name
+		}
+		return names
+	default:
+		return nil
+	}
+}
+
+// Import executes a module and declares its exports as a constant namespace
+//
+//   import "common/pricing.ncs" as pricing
+//
+// Modules are parsed once when the importing script is parsed but executed every time the
+// import statement is executed. Modules don't see the variables of the importing script.
+type Import struct {
+	Path      string
+	Namespace string
+	module    *StatementBlock
+}
+
+// Execute executes the module and returns the Module providing its exports
+func (statement *Import) Execute(variables *Variables) (interface{}, error) {
+	modulevariables := NewVariables(nil)
+	modulevariables.SetOptions(variables.GetOptions())
+	for _, token := range statement.module.Body {
+		if _, err := token.Execute(modulevariables); err != nil {
+			return nil, err
+		}
+	}
+
+	module := &Module{Path: statement.Path, members: make(map[string]interface{})}
+	for _, token := range statement.module.Body {
+		if export, ok := token.(*Export); ok {
+			for _, name := range export.names() {
+				module.members[name] = modulevariables.values[name]
+			}
+		}
+	}
+
+	if err := variables.declareVariable(statement.Namespace, module, true); err != nil {
+		return nil, err
+	}
+	return module, nil
+}
+
+// moduleNamespace determines the default namespace of a module which is its file name
+// without extension
+func moduleNamespace(modulepath string) string {
+	name := path.Base(modulepath)
+	if extension := path.Ext(name); len(extension) > 0 {
+		name = strings.TrimSuffix(name, extension)
+	}
+
+	if len(readIdentifier(&name, 0)) != len(name) {
+		return ""
+	}
+	return name
+}
+
+// parseModule parses the module with the specified path or returns the cached module
+func (parser *Parser) parseModule(modulepath string) (*StatementBlock, error) {
+	if parser.modules.loader == nil {
+		return nil, errors.New("No module loader configured")
+	}
+
+	for _, importing := range parser.importing {
+		if importing == modulepath {
+			return nil, fmt.Errorf("Cyclic import of module '%s'", modulepath)
+		}
+	}
+
+	if module := parser.modules.get(modulepath); module != nil {
+		return module, nil
+	}
+
+	code, err := parser.modules.loader.Load(modulepath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load module '%s': %v", modulepath, err)
+	}
+
+	moduleparser := *parser
+	moduleparser.importing = append(append([]string{}, parser.importing...), modulepath)
+
+	index := 0
+	module, err := moduleparser.parseStatementBlock(nil, &code, &index, true)
+	if parseerror, ok := err.(*ParseError); ok {
+		return nil, fmt.Errorf("Error in module '%s' at index %d: %s", modulepath, parseerror.Position, parseerror.Message)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error in module '%s': %v", modulepath, err)
+	}
+
+	parser.modules.add(modulepath, module)
+	return module, nil
+}
@@ -0,0 +1,83 @@
+package scripts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Namespace functions, constants and nested namespaces registered by the host
+type Namespace struct {
+	Name    string
+	members map[string]interface{}
+}
+
+// Member returns a function, constant or nested namespace of the namespace
+func (namespace *Namespace) Member(name string) (interface{}, bool) {
+	value, ok := namespace.members[name]
+	return value, ok
+}
+
+// String returns the name of the namespace
+func (namespace *Namespace) String() string {
+	return namespace.Name
+}
+
+// Namespaces namespaces available to parsed scripts
+//
+// Scripts access members of namespaces like members of host values, eg. math.sin(x) or
+// app.config.region. Variables defined by the host or declared by scripts take precedence
+// over namespaces with the same name.
+type Namespaces struct {
+	roots map[string]*Namespace
+}
+
+// NewNamespaces creates a new namespace registry
+func NewNamespaces() *Namespaces {
+	return &Namespaces{roots: make(map[string]*Namespace)}
+}
+
+// Register registers a function or constant under a dotted name like 'math.sin'
+//
+// Namespaces are created as needed. Functions are called like other host functions.
+func (namespaces *Namespaces) Register(name string, value interface{}) error {
+	path := strings.Split(name, ".")
+	if len(path) < 2 {
+		return fmt.Errorf("'%s' has no namespace", name)
+	}
+	for _, part := range path {
+		if len(part) == 0 || len(readIdentifier(&part, 0)) != len(part) {
+			return fmt.Errorf("'%s' is no valid name", name)
+		}
+	}
+
+	namespace, ok := namespaces.roots[path[0]]
+	if !ok {
+		namespace = &Namespace{Name: path[0], members: make(map[string]interface{})}
+		namespaces.roots[path[0]] = namespace
+	}
+
+	for i := 1; i < len(path)-1; i++ {
+		member, exists := namespace.members[path[i]]
+		if !exists {
+			member = &Namespace{Name: strings.Join(path[:i+1], "."), members: make(map[string]interface{})}
+			namespace.members[path[i]] = member
+		}
+
+		nested, ok := member.(*Namespace)
+		if !ok {
+			return fmt.Errorf("'%s' is not a namespace", strings.Join(path[:i+1], "."))
+		}
+		namespace = nested
+	}
+
+	if _, exists := namespace.members[path[len(path)-1]]; exists {
+		return fmt.Errorf("'%s' is already registered", name)
+	}
+	namespace.members[path[len(path)-1]] = value
+	return nil
+}
+
+// root returns the top level namespace with the specified name or nil if it doesn't exist
+func (namespaces *Namespaces) root(name string) *Namespace {
+	return namespaces.roots[name]
+}
@@ -0,0 +1,155 @@
+package scripts
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type hostType struct {
+	structtype  reflect.Type
+	constructor interface{}
+}
+
+// Types registry of host types scripts can construct using new
+type Types struct {
+	types map[string]*hostType
+}
+
+// NewTypes creates a new type registry
+func NewTypes() *Types {
+	return &Types{types: make(map[string]*hostType)}
+}
+
+// Register registers a struct type which is constructed using its zero value
+func (types *Types) Register(name string, structtype reflect.Type) error {
+	if structtype.Kind() == reflect.Ptr {
+		structtype = structtype.Elem()
+	}
+	if structtype.Kind() != reflect.Struct {
+		return fmt.Errorf("'%v' is not a struct type", structtype)
+	}
+
+	types.types[name] = &hostType{structtype: structtype}
+	return nil
+}
+
+// RegisterConstructor registers a type which is constructed by calling a go func
+//
+// The type of the first result of the constructor is the type being constructed, the
+// constructor can return an error as second result.
+func (types *Types) RegisterConstructor(name string, constructor interface{}) error {
+	constructortype := reflect.TypeOf(constructor)
+	if constructortype == nil || constructortype.Kind() != reflect.Func || constructortype.NumOut() == 0 {
+		return fmt.Errorf("Constructor of '%s' has to be a func returning the constructed value", name)
+	}
+
+	types.types[name] = &hostType{
+		structtype:  constructortype.Out(0),
+		constructor: constructor}
+	return nil
+}
+
+// IsRegistered determines whether a type with the specified name is registered
+func (types *Types) IsRegistered(name string) bool {
+	_, ok := types.types[name]
+	return ok
+}
+
+// FieldInitializer assignment of a field in a new expression
+type FieldInitializer struct {
+	Name  string
+	Value Token
+}
+
+// New constructs a host type
+//
+//   new Address { Street = "Main Street", Zip = 12345 }
+//   new Point(1, 2)
+//
+// Types without constructor are created as pointer to their zero value. Fields are
+// assigned after construction and matched like members.
+type New struct {
+	TypeName     string
+	Arguments    []Token
+	Initializers []*FieldInitializer
+	types        *Types
+	conversions  *Conversions
+}
+
+// Execute constructs the value
+func (construction *New) Execute(variables *Variables) (interface{}, error) {
+	registered, ok := construction.types.types[construction.TypeName]
+	if !ok {
+		return nil, fmt.Errorf("Type '%s' is not registered", construction.TypeName)
+	}
+
+	var value reflect.Value
+	if registered.constructor != nil {
+		call := &Call{Name: construction.TypeName, Parameters: construction.Arguments, conversions: construction.conversions}
+		arguments, err := call.arguments(variables)
+		if err != nil {
+			return nil, err
+		}
+
+		constructed, err := call.invoke(registered.constructor, arguments)
+		if err != nil {
+			return nil, err
+		}
+		if constructed == nil {
+			return nil, fmt.Errorf("Constructor of '%s' returned null", construction.TypeName)
+		}
+		value = reflect.ValueOf(constructed)
+	} else {
+		if len(construction.Arguments) > 0 {
+			return nil, fmt.Errorf("Type '%s' has no constructor accepting arguments", construction.TypeName)
+		}
+		value = reflect.New(registered.structtype)
+	}
+
+	if len(construction.Initializers) == 0 {
+		return value.Interface(), nil
+	}
+
+	// values returned by constructors are copied to be able to assign fields
+	target := value
+	if value.Kind() != reflect.Ptr {
+		target = reflect.New(value.Type())
+		target.Elem().Set(value)
+	}
+
+	for _, initializer := range construction.Initializers {
+		if err := construction.initialize(target, initializer, variables); err != nil {
+			return nil, err
+		}
+	}
+
+	if value.Kind() != reflect.Ptr {
+		return target.Elem().Interface(), nil
+	}
+	return target.Interface(), nil
+}
+
+func (construction *New) initialize(target reflect.Value, initializer *FieldInitializer, variables *Variables) error {
+	field, err := memberField(target.Interface(), initializer.Name)
+	if err != nil {
+		return err
+	}
+	if !field.IsValid() {
+		return fmt.Errorf("Member with name '%s' not found on '%s'", initializer.Name, construction.TypeName)
+	}
+	if !field.CanSet() {
+		return fmt.Errorf("Member '%s' can not be assigned", initializer.Name)
+	}
+
+	value, err := initializer.Value.Execute(variables)
+	if err != nil {
+		return err
+	}
+
+	converted, err := construction.conversions.convertArgument(value, field.Type())
+	if err != nil {
+		return fmt.Errorf("Member '%s': %v", initializer.Name, err)
+	}
+	field.Set(converted)
+	return nil
+}
@@ -0,0 +1,277 @@
+package scripts
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// numericType type in which numeric operations are computed
+type numericType int8
+
+const (
+	numericNone numericType = iota
+	numericInt
+	numericSmallUnsigned
+	numericUInt
+	numericLong
+	numericULong
+	numericFloat
+	numericDouble
+	numericDecimal
+	numericBigInt
+)
+
+// numericTypeOf determines the numeric type of a value
+//
+// Integers smaller than 32 bit are promoted to int like in C#, go int and uint are
+// treated as 64 bit integers.
+func numericTypeOf(value interface{}) numericType {
+	switch value.(type) {
+	case int8, int16, int32, Char:
+		return numericInt
+	case uint8, uint16:
+		return numericSmallUnsigned
+	case uint32:
+		return numericUInt
+	case int, int64:
+		return numericLong
+	case uint, uint64:
+		return numericULong
+	case float32:
+		return numericFloat
+	case float64:
+		return numericDouble
+	case Decimal:
+		return numericDecimal
+	case *big.Int:
+		return numericBigInt
+	default:
+		return numericNone
+	}
+}
+
+// isIntegralType determines whether a numeric type represents integers
+func isIntegralType(numeric numericType) bool {
+	switch numeric {
+	case numericInt, numericSmallUnsigned, numericUInt, numericLong, numericULong, numericBigInt:
+		return true
+	default:
+		return false
+	}
+}
+
+// promoteNumericTypes determines the type used to compute a binary operation
+//
+// This follows the binary numeric promotion of C#:
+//   decimal if one operand is decimal
+//   double if one operand is double, or float combined with bigint
+//   float if one operand is float
+//   bigint if one operand is bigint
+//   ulong if one operand is ulong
+//   long if one operand is long, or uint combined with a signed integer
+//   uint if one operand is uint and the other is unsigned
+//   int otherwise
+func promoteNumericTypes(lhs numericType, rhs numericType) numericType {
+	switch {
+	case lhs == numericNone || rhs == numericNone:
+		return numericNone
+	case lhs == numericDecimal || rhs == numericDecimal:
+		return numericDecimal
+	case lhs == numericDouble || rhs == numericDouble:
+		return numericDouble
+	case lhs == numericFloat || rhs == numericFloat:
+		if lhs == numericBigInt || rhs == numericBigInt {
+			return numericDouble
+		}
+		return numericFloat
+	case lhs == numericBigInt || rhs == numericBigInt:
+		return numericBigInt
+	case lhs == numericULong || rhs == numericULong:
+		return numericULong
+	case lhs == numericLong || rhs == numericLong:
+		return numericLong
+	case lhs == numericUInt && (rhs == numericUInt || rhs == numericSmallUnsigned):
+		return numericUInt
+	case rhs == numericUInt && lhs == numericSmallUnsigned:
+		return numericUInt
+	case lhs == numericUInt || rhs == numericUInt:
+		return numericLong
+	default:
+		return numericInt
+	}
+}
+
+// convertNumeric converts a numeric value to the specified numeric type
+func convertNumeric(value interface{}, target numericType) (interface{}, error) {
+	if character, ok := value.(Char); ok {
+		value = int32(character)
+	}
+
+	switch target {
+	case numericInt:
+		return cast.ToInt32(value), nil
+	case numericUInt:
+		return cast.ToUint32(value), nil
+	case numericLong:
+		return cast.ToInt64(value), nil
+	case numericULong:
+		switch v := value.(type) {
+		case int, int8, int16, int32, int64:
+			if cast.ToInt64(v) < 0 {
+				return nil, fmt.Errorf("Negative value '%v' can not be combined with an ulong", value)
+			}
+		}
+		return cast.ToUint64(value), nil
+	case numericFloat:
+		return float32(toFloat64(value)), nil
+	case numericDouble:
+		return toFloat64(value), nil
+	case numericDecimal:
+		return toDecimal(value)
+	case numericBigInt:
+		return toBigInteger(value)
+	default:
+		return nil, fmt.Errorf("'%v' is not a number", value)
+	}
+}
+
+func toFloat64(value interface{}) float64 {
+	switch v := value.(type) {
+	case Decimal:
+		return v.Float64()
+	case *big.Int:
+		float, _ := new(big.Float).SetInt(v).Float64()
+		return float
+	case Char:
+		return float64(v)
+	default:
+		return cast.ToFloat64(value)
+	}
+}
+
+// parseNumericString converts a string operand to a number
+//
+// **Returns**
+//   the number as long or double and whether the string contained a number
+func parseNumericString(value string) (interface{}, bool) {
+	value = strings.TrimSpace(value)
+	if integer, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return integer, true
+	}
+	if float, err := strconv.ParseFloat(value, 64); err == nil {
+		return float, true
+	}
+	return nil, false
+}
+
+// numericOperand returns the numeric representation of an operand
+//
+// Strings containing a number are converted to long or double, other values are
+// returned unchanged.
+func numericOperand(value interface{}) interface{} {
+	if text, ok := value.(string); ok {
+		if number, ok := parseNumericString(text); ok {
+			return number
+		}
+	}
+	return value
+}
+
+// promoteOperands converts both operands to the type in which a binary operation is computed
+func promoteOperands(lhs interface{}, rhs interface{}) (interface{}, interface{}, numericType, error) {
+	target := promoteNumericTypes(numericTypeOf(lhs), numericTypeOf(rhs))
+	if target == numericNone {
+		return nil, nil, numericNone, nil
+	}
+
+	lhs, err := convertNumeric(lhs, target)
+	if err != nil {
+		return nil, nil, numericNone, err
+	}
+
+	rhs, err = convertNumeric(rhs, target)
+	if err != nil {
+		return nil, nil, numericNone, err
+	}
+
+	return lhs, rhs, target, nil
+}
+
+// compareNumbers compares two numeric values
+//
+// **Returns**
+//   comparision result (-1, 0, 1), whether the values are ordered (NaN is not) and any error
+func compareNumbers(lhs interface{}, rhs interface{}) (int, bool, error) {
+	lhstype := numericTypeOf(lhs)
+	rhstype := numericTypeOf(rhs)
+	if isIntegralType(lhstype) && isIntegralType(rhstype) {
+		// integers are compared exactly regardless of signedness
+		lhsbig, err := toBigInteger(lhs)
+		if err != nil {
+			return 0, false, err
+		}
+		rhsbig, err := toBigInteger(rhs)
+		if err != nil {
+			return 0, false, err
+		}
+		return lhsbig.Cmp(rhsbig), true, nil
+	}
+
+	promotedlhs, promotedrhs, target, err := promoteOperands(lhs, rhs)
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch target {
+	case numericFloat, numericDouble:
+		lhsfloat := toFloat64(promotedlhs)
+		rhsfloat := toFloat64(promotedrhs)
+		if math.IsNaN(lhsfloat) || math.IsNaN(rhsfloat) {
+			return 0, false, nil
+		}
+		switch {
+		case lhsfloat < rhsfloat:
+			return -1, true, nil
+		case lhsfloat > rhsfloat:
+			return 1, true, nil
+		default:
+			return 0, true, nil
+		}
+	case numericDecimal:
+		return promotedlhs.(Decimal).Cmp(promotedrhs.(Decimal)), true, nil
+	default:
+		return 0, false, fmt.Errorf("Comparision not supported for '%v' and '%v'", lhs, rhs)
+	}
+}
+
+// integerResult wraps the result of an integer operation to the bits of the operation type
+//
+// **Returns**
+//   the wrapped result and whether the operation overflowed
+func integerResult(result *big.Int, target numericType) (interface{}, bool) {
+	bits := wrapBits(result)
+	switch target {
+	case numericInt:
+		return int32(bits), !result.IsInt64() || result.Int64() != int64(int32(bits))
+	case numericUInt:
+		return uint32(bits), !result.IsUint64() || result.Uint64() != uint64(uint32(bits))
+	case numericLong:
+		return int64(bits), !result.IsInt64()
+	case numericULong:
+		return bits, !result.IsUint64()
+	default:
+		return result, false
+	}
+}
+
+var bigTwo64 = new(big.Int).Lsh(big.NewInt(1), 64)
+
+// wrapBits returns the lower 64 bits of an integer in two's complement
+func wrapBits(value *big.Int) uint64 {
+	return new(big.Int).Mod(value, bigTwo64).Uint64()
+}
@@ -1,10 +1,12 @@
 package scripts
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
-
-	"github.com/spf13/cast"
+	"time"
 )
 
 type OperatorType int8
@@ -51,6 +53,14 @@ const (
 	OP_OrAssign
 	OP_XorAssign
 	OP_Lambda
+
+	// operators added later are appended to keep the values of existing operators
+	OP_FromEnd
+	OP_Range
+	OP_RangeExclusive
+	OP_Is
+	OP_As
+	OP_In
 )
 
 const (
@@ -59,6 +69,77 @@ const (
 	OP_Binary
 )
 
+// operatorPrecedence precedence of operators, operators with lower values are applied first
+var operatorPrecedence = map[OperatorType]int{
+	OP_Not:            0,
+	OP_Neg:            1,
+	OP_Com:            2,
+	OP_FromEnd:        3,
+	OP_Inc:            4,
+	OP_Dec:            5,
+	OP_Div:            6,
+	OP_Mul:            7,
+	OP_Mod:            8,
+	OP_Sub:            9,
+	OP_Add:            10,
+	OP_BitAnd:         11,
+	OP_BitOr:          12,
+	OP_BitXor:         13,
+	OP_Shl:            14,
+	OP_Shr:            15,
+	OP_Rol:            16,
+	OP_Ror:            17,
+	OP_Range:          18,
+	OP_RangeExclusive: 19,
+	OP_And:            20,
+	OP_Or:             21,
+	OP_Xor:            22,
+	OP_Less:           23,
+	OP_LessEqual:      24,
+	OP_Greater:        25,
+	OP_GreaterEqual:   26,
+	OP_Is:             27,
+	OP_As:             28,
+	OP_In:             29,
+	OP_Equal:          30,
+	OP_NotEqual:       31,
+	OP_Match:          32,
+	OP_NotMatch:       33,
+	OP_Assign:         34,
+	OP_AddAssign:      35,
+	OP_SubAssign:      36,
+	OP_DivAssign:      37,
+	OP_MulAssign:      38,
+	OP_ModAssign:      39,
+	OP_ShlAssign:      40,
+	OP_ShrAssign:      41,
+	OP_AndAssign:      42,
+	OP_OrAssign:       43,
+	OP_XorAssign:      44,
+	OP_Lambda:         45,
+}
+
+// operatorSymbols symbols of the operators as written in scripts
+var operatorSymbols = map[OperatorType]string{
+	OP_Not: "!", OP_Neg: "-", OP_Com: "~", OP_FromEnd: "^", OP_Inc: "++", OP_Dec: "--",
+	OP_Div: "/", OP_Mul: "*", OP_Mod: "%", OP_Sub: "-", OP_Add: "+",
+	OP_BitAnd: "&", OP_BitOr: "|", OP_BitXor: "^", OP_Shl: "<<", OP_Shr: ">>", OP_Rol: "<<<", OP_Ror: ">>>",
+	OP_Range: "..", OP_RangeExclusive: "..<", OP_And: "&&", OP_Or: "||", OP_Xor: "^^",
+	OP_Less: "<", OP_LessEqual: "<=", OP_Greater: ">", OP_GreaterEqual: ">=",
+	OP_Is: "is", OP_As: "as", OP_In: "in", OP_Equal: "==", OP_NotEqual: "!=", OP_Match: "~~", OP_NotMatch: "!~",
+	OP_Assign: "=", OP_AddAssign: "+=", OP_SubAssign: "-=", OP_DivAssign: "/=", OP_MulAssign: "*=", OP_ModAssign: "%=",
+	OP_ShlAssign: "<<=", OP_ShrAssign: ">>=", OP_AndAssign: "&=", OP_OrAssign: "|=", OP_XorAssign: "^=",
+	OP_Lambda: "=>",
+}
+
+// String returns the symbol of the operator
+func (operatortype OperatorType) String() string {
+	if symbol, ok := operatorSymbols[operatortype]; ok {
+		return symbol
+	}
+	return fmt.Sprintf("%d", int8(operatortype))
+}
+
 // Operator operates on one or two tokens depending on class to produce a result
 type Operator struct {
 	Type  OperatorType
@@ -82,28 +163,24 @@ func (op *Operator) Execute(variables *Variables) (interface{}, error) {
 		if err == nil {
 			value = !value.(bool)
 		}
-	case OP_Less:
-		value, err = op.less(variables)
-	case OP_LessEqual:
-		value, err = op.lessEqual(variables)
-	case OP_Greater:
-		value, err = op.lessEqual(variables)
-		if err == nil {
-			value = !value.(bool)
-		}
-	case OP_GreaterEqual:
-		value, err = op.less(variables)
-		if err == nil {
-			value = !value.(bool)
-		}
-	case OP_Add:
-		value, err = op.add(variables)
-	case OP_Sub:
-		value, err = op.sub(variables)
-	case OP_Mul:
-		value, err = op.mul(variables)
-	case OP_Div:
-		value, err = op.div(variables)
+	case OP_Less, OP_LessEqual, OP_Greater, OP_GreaterEqual:
+		value, err = op.compare(variables)
+	case OP_Is, OP_As:
+		value, err = op.typeTest(variables)
+	case OP_Range, OP_RangeExclusive:
+		value, err = op.rangeValue(variables)
+	case OP_FromEnd:
+		value, err = op.fromEnd(variables)
+	case OP_In:
+		value, err = op.contains(variables)
+	case OP_Match, OP_NotMatch:
+		value, err = op.regexMatch(variables)
+	case OP_Assign:
+		value, err = op.assign(variables)
+	case OP_Add, OP_Sub, OP_Mul, OP_Div, OP_Mod:
+		value, err = op.arithmetic(variables)
+	case OP_Shl, OP_Shr:
+		value, err = op.shift(variables)
 	default:
 		return nil, fmt.Errorf("Operator '%v' not implemented", op.Type)
 	}
@@ -115,650 +192,330 @@ func (op *Operator) Execute(variables *Variables) (interface{}, error) {
 	return value, nil
 }
 
-func (op *Operator) equal(variables *Variables) (bool, error) {
+func (op *Operator) operands(variables *Variables) (interface{}, interface{}, error) {
 	lhs, err := op.LHS.Execute(variables)
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
 
 	rhs, err := op.RHS.Execute(variables)
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
 
-	return fmt.Sprintf("%v", lhs) == fmt.Sprintf("%v", rhs), nil
+	return lhs, rhs, nil
 }
 
-func (op *Operator) less(variables *Variables) (bool, error) {
-	lhs, err := op.LHS.Execute(variables)
+// equal evaluates the equality operators == and !=
+//
+// Values are compared by type (see valuesEqual). If LooseEquality is enabled in the
+// execution options the string representations of both values are compared instead.
+func (op *Operator) equal(variables *Variables) (bool, error) {
+	lhs, rhs, err := op.operands(variables)
 	if err != nil {
 		return false, err
 	}
 
-	rhs, err := op.RHS.Execute(variables)
-	if err != nil {
-		return false, err
+	if variables.GetOptions().LooseEquality {
+		return fmt.Sprintf("%v", lhs) == fmt.Sprintf("%v", rhs), nil
 	}
 
-	switch lhs.(type) {
-	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) < rhs.(int64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) < rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_INT)
-				if err != nil {
-					cmp, err = castValue(rhs, CAST_STRING)
-					return lhs.(string) < cmp.(string), nil
-				}
-				return lhs.(int64) < cmp.(int64), nil
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
-		}
-	case float32, float64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) < rhs.(float64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) < rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_DOUBLE)
-				if err != nil {
-					cmp, err = castValue(rhs, CAST_STRING)
-					return lhs.(string) < cmp.(string), nil
-				}
-				return lhs.(float64) < cmp.(float64), nil
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
-		}
-	case string:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			rhs, err = castValue(rhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_INT)
-				if err != nil {
-					cmp, err = castValue(lhs, CAST_STRING)
-					return lhs.(string) < cmp.(string), nil
-				}
-				return lhs.(int64) < cmp.(int64), nil
-			}
-		case float32, float64:
-			rhs, err = castValue(rhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_DOUBLE)
-				if err != nil {
-					cmp, err = castValue(lhs, CAST_STRING)
-					return lhs.(string) < cmp.(string), nil
-				}
-				return lhs.(float64) < cmp.(float64), nil
-			}
-		case string:
-			return lhs.(string) < rhs.(string), nil
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
-		}
-	default:
-		return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(lhs))
-	}
-
-	return false, err
+	return valuesEqual(lhs, rhs)
 }
 
-func (op *Operator) neg(variables *Variables) (interface{}, error) {
-	lhs, err := op.LHS.Execute(variables)
+// compare evaluates the relational operators <, <=, > and >= (see compareValues)
+func (op *Operator) compare(variables *Variables) (bool, error) {
+	lhs, rhs, err := op.operands(variables)
 	if err != nil {
 		return false, err
 	}
 
-	switch v := lhs.(type) {
-	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-		return -cast.ToInt64(v), nil
-	case float32, float64:
-		return -cast.ToFloat64(v), nil
-	default:
-		return false, fmt.Errorf("Negation not supported for '%v'", reflect.ValueOf(lhs))
-	}
-}
-
-func (op *Operator) lessEqual(variables *Variables) (bool, error) {
-	lhs, err := op.LHS.Execute(variables)
+	comparision, ordered, err := compareValues(lhs, rhs)
 	if err != nil {
 		return false, err
 	}
 
-	rhs, err := op.RHS.Execute(variables)
-	if err != nil {
-		return false, err
+	if !ordered {
+		return false, nil
 	}
 
-	switch lhs.(type) {
-	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) <= rhs.(int64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) <= rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_INT)
-				if err != nil {
-					cmp, err = castValue(rhs, CAST_STRING)
-					return lhs.(string) <= cmp.(string), nil
-				}
-				return lhs.(int64) <= cmp.(int64), nil
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
-		}
-	case float32, float64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) <= rhs.(float64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) <= rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_DOUBLE)
-				if err != nil {
-					cmp, err = castValue(rhs, CAST_STRING)
-					return lhs.(string) <= cmp.(string), nil
-				}
-				return lhs.(float64) <= cmp.(float64), nil
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
-		}
-	case string:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			rhs, err = castValue(rhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_INT)
-				if err != nil {
-					cmp, err = castValue(lhs, CAST_STRING)
-					return lhs.(string) <= cmp.(string), nil
-				}
-				return lhs.(int64) <= cmp.(int64), nil
-			}
-		case float32, float64:
-			rhs, err = castValue(rhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_DOUBLE)
-				if err != nil {
-					cmp, err = castValue(lhs, CAST_STRING)
-					return lhs.(string) <= cmp.(string), nil
-				}
-				return lhs.(float64) <= cmp.(float64), nil
-			}
-		case string:
-			return lhs.(string) <= rhs.(string), nil
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
+	switch op.Type {
+	case OP_Less:
+		return comparision < 0, nil
+	case OP_LessEqual:
+		return comparision <= 0, nil
+	case OP_Greater:
+		return comparision > 0, nil
+	default:
+		return comparision >= 0, nil
+	}
+}
+
+// compareValues compares two values
+//
+// Host types implementing Comparable compare on their own, numbers are compared by value
+// regardless of their type, strings are compared ordinally. If only one operand is a
+// string it is compared as number if it contains one.
+//
+// **Returns**
+//
+//	comparision result (-1, 0, 1), whether the values are ordered (NaN is not) and any error
+func compareValues(lhs interface{}, rhs interface{}) (int, bool, error) {
+	comparision, handled, err := compareHostValues(lhs, rhs)
+	if handled {
+		return comparision, true, err
+	}
+
+	lhsstring, lhsisstring := lhs.(string)
+	rhsstring, rhsisstring := rhs.(string)
+	switch {
+	case lhsisstring && rhsisstring:
+		return compareStrings(lhsstring, rhsstring), true, nil
+	case lhsisstring || rhsisstring:
+		comparision, ordered, err := compareNumbers(numericOperand(lhs), numericOperand(rhs))
+		if err != nil {
+			return compareStrings(fmt.Sprintf("%v", lhs), fmt.Sprintf("%v", rhs)), true, nil
 		}
+		return comparision, ordered, nil
 	default:
-		return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(lhs))
+		return compareNumbers(lhs, rhs)
 	}
+}
 
-	return false, err
+func compareStrings(lhs string, rhs string) int {
+	switch {
+	case lhs < rhs:
+		return -1
+	case lhs > rhs:
+		return 1
+	default:
+		return 0
+	}
 }
 
-func (op *Operator) add(variables *Variables) (interface{}, error) {
+func (op *Operator) neg(variables *Variables) (interface{}, error) {
 	lhs, err := op.LHS.Execute(variables)
 	if err != nil {
 		return false, err
 	}
 
-	rhs, err := op.RHS.Execute(variables)
-	if err != nil {
-		return false, err
+	if negater, ok := lhs.(ScriptNegater); ok {
+		return negater.ScriptNeg()
 	}
 
-	switch lhs.(type) {
-	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) + rhs.(int64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) + rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_INT)
-				if err != nil {
-					cmp, err = castValue(rhs, CAST_STRING)
-					return lhs.(string) + cmp.(string), nil
-				}
-				return lhs.(int64) + cmp.(int64), nil
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
-		}
-	case float32, float64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) + rhs.(float64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) + rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_DOUBLE)
-				if err != nil {
-					cmp, err = castValue(rhs, CAST_STRING)
-					return lhs.(string) + cmp.(string), nil
-				}
-				return lhs.(float64) + cmp.(float64), nil
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
+	if duration, ok := lhs.(time.Duration); ok {
+		return -duration, nil
+	}
+
+	switch numericTypeOf(lhs) {
+	case numericInt, numericSmallUnsigned, numericUInt, numericLong:
+		target := numericTypeOf(lhs)
+		switch target {
+		case numericSmallUnsigned:
+			target = numericInt
+		case numericUInt:
+			// negation of uint results in long like in C#
+			target = numericLong
 		}
-	case string:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			rhs, err = castValue(rhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_INT)
-				if err != nil {
-					cmp, err = castValue(lhs, CAST_STRING)
-					return lhs.(string) + cmp.(string), nil
-				}
-				return lhs.(int64) + cmp.(int64), nil
-			}
-		case float32, float64:
-			rhs, err = castValue(rhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_DOUBLE)
-				if err != nil {
-					cmp, err = castValue(lhs, CAST_STRING)
-					return lhs.(string) + cmp.(string), nil
-				}
-				return lhs.(float64) + cmp.(float64), nil
-			}
-		case string:
-			return lhs.(string) + rhs.(string), nil
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
+
+		value, err := toBigInteger(lhs)
+		if err != nil {
+			return nil, err
 		}
+
+		return op.integerResult(variables, new(big.Int).Neg(value), target, lhs, nil)
+	case numericFloat:
+		return -lhs.(float32), nil
+	case numericDouble:
+		return -lhs.(float64), nil
+	case numericDecimal:
+		return lhs.(Decimal).Neg(), nil
+	case numericBigInt:
+		return new(big.Int).Neg(lhs.(*big.Int)), nil
 	default:
-		return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(lhs))
+		return false, fmt.Errorf("Negation not supported for '%v'", reflect.ValueOf(lhs))
 	}
-
-	return false, err
 }
 
-func (op *Operator) sub(variables *Variables) (interface{}, error) {
-	lhs, err := op.LHS.Execute(variables)
+// arithmetic evaluates the binary operators +, -, *, / and %
+//
+// Host types can provide their own implementation (see overloadedArithmetic), dates and
+// durations are handled by timeArithmetic. Otherwise operands are converted using binary
+// numeric promotion (see promoteNumericTypes) and the result has the promoted type.
+// Strings containing numbers are treated as numbers, adding a string to anything else
+// concatenates both values.
+func (op *Operator) arithmetic(variables *Variables) (interface{}, error) {
+	lhs, rhs, err := op.operands(variables)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	rhs, err := op.RHS.Execute(variables)
-	if err != nil {
-		return false, err
+	if result, handled, err := op.overloadedArithmetic(lhs, rhs); handled {
+		return result, err
 	}
 
-	switch lhs.(type) {
-	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) - rhs.(int64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) - rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) - cmp.(int64), nil
-				}
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
-		}
-	case float32, float64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) - rhs.(float64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) - rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) - cmp.(float64), nil
-				}
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
-		}
-	case string:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			rhs, err = castValue(rhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) - cmp.(int64), nil
-				}
-			}
-		case float32, float64:
-			rhs, err = castValue(rhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_DOUBLE)
-				if err != nil {
-					return lhs.(float64) - cmp.(float64), nil
-				}
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
-		}
-	default:
-		return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(lhs))
+	if result, handled, err := op.timeArithmetic(lhs, rhs); handled {
+		return result, err
 	}
 
-	return false, err
-}
+	_, lhsisstring := lhs.(string)
+	_, rhsisstring := rhs.(string)
+	if lhsisstring || rhsisstring {
+		_, lhsischar := lhs.(Char)
+		_, rhsischar := rhs.(Char)
+		if op.Type == OP_Add && (lhsisstring && rhsisstring || lhsischar || rhsischar || numericTypeOf(numericOperand(lhs)) == numericNone || numericTypeOf(numericOperand(rhs)) == numericNone) {
+			return fmt.Sprintf("%v%v", lhs, rhs), nil
+		}
 
-func (op *Operator) mul(variables *Variables) (interface{}, error) {
-	lhs, err := op.LHS.Execute(variables)
-	if err != nil {
-		return false, err
+		lhs = numericOperand(lhs)
+		rhs = numericOperand(rhs)
 	}
 
-	rhs, err := op.RHS.Execute(variables)
+	promotedlhs, promotedrhs, target, err := promoteOperands(lhs, rhs)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	switch lhs.(type) {
-	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) * rhs.(int64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) * rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) * cmp.(int64), nil
-				}
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
+	switch target {
+	case numericInt, numericUInt, numericLong, numericULong, numericBigInt:
+		lhsbig, _ := toBigInteger(promotedlhs)
+		rhsbig, _ := toBigInteger(promotedrhs)
+		result, err := op.bigIntegerArithmetic(lhsbig, rhsbig)
+		if err != nil {
+			return nil, err
 		}
-	case float32, float64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) * rhs.(float64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) * rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) * cmp.(float64), nil
-				}
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
+		return op.integerResult(variables, result, target, lhs, rhs)
+	case numericFloat:
+		result, err := op.floatArithmetic(float64(promotedlhs.(float32)), float64(promotedrhs.(float32)))
+		return float32(result), err
+	case numericDouble:
+		return op.floatArithmetic(promotedlhs.(float64), promotedrhs.(float64))
+	case numericDecimal:
+		return op.decimalArithmetic(promotedlhs.(Decimal), promotedrhs.(Decimal))
+	default:
+		return nil, fmt.Errorf("Operator '%v' not supported for '%v' and '%v'", op.Type, reflect.ValueOf(lhs), reflect.ValueOf(rhs))
+	}
+}
+
+// integerResult converts the result of an integer operation to the operation type
+//
+// If the result overflows the operation type and PromoteBigIntegers is enabled in the
+// execution options, the result is returned as long if it fits into 64 bits and the
+// operation type is int or uint, otherwise as big integer. If CheckedArithmetic is
+// enabled an OverflowError is returned, otherwise the result wraps around.
+func (op *Operator) integerResult(variables *Variables, result *big.Int, target numericType, lhs interface{}, rhs interface{}) (interface{}, error) {
+	value, overflow := integerResult(result, target)
+	if overflow {
+		options := variables.GetOptions()
+		if options.PromoteBigIntegers {
+			if (target == numericInt || target == numericUInt) && result.IsInt64() {
+				return result.Int64(), nil
+			}
+			return result, nil
 		}
-	case string:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			rhs, err = castValue(rhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) * cmp.(int64), nil
-				}
-			}
-		case float32, float64:
-			rhs, err = castValue(rhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_DOUBLE)
-				if err != nil {
-					return lhs.(float64) * cmp.(float64), nil
-				}
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
+		if options.CheckedArithmetic {
+			return nil, &OverflowError{Operator: op.Type, LHS: lhs, RHS: rhs}
 		}
-	default:
-		return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(lhs))
 	}
 
-	return false, err
+	return value, nil
 }
 
-func (op *Operator) div(variables *Variables) (interface{}, error) {
-	lhs, err := op.LHS.Execute(variables)
+// shift evaluates the shift operators << and >>
+//
+// Like in C# the result has the promoted type of the left operand and the shift count is
+// masked to the bit size of that type. Big integers are shifted without limitation.
+func (op *Operator) shift(variables *Variables) (interface{}, error) {
+	lhs, rhs, err := op.operands(variables)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	rhs, err := op.RHS.Execute(variables)
-	if err != nil {
-		return false, err
+	target := numericTypeOf(lhs)
+	if !isIntegralType(target) || !isIntegralType(numericTypeOf(rhs)) {
+		return nil, fmt.Errorf("Shift not supported for '%v' and '%v'", reflect.ValueOf(lhs), reflect.ValueOf(rhs))
+	}
+	if target == numericSmallUnsigned {
+		target = numericInt
 	}
 
-	switch lhs.(type) {
-	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) / rhs.(int64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) / rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) / cmp.(int64), nil
-				}
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
+	value, _ := toBigInteger(lhs)
+	countvalue, _ := toBigInteger(rhs)
+	if !countvalue.IsInt64() {
+		return nil, fmt.Errorf("Shift count '%v' out of range", rhs)
+	}
+
+	count := countvalue.Int64()
+	switch target {
+	case numericInt, numericUInt:
+		count &= 31
+	case numericLong, numericULong:
+		count &= 63
+	default:
+		if count < 0 {
+			return nil, fmt.Errorf("Negative shift count '%v'", rhs)
 		}
-	case float32, float64:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) / rhs.(float64), nil
-				}
-			}
-		case float32, float64:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				rhs, err = castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) / rhs.(float64), nil
-				}
-			}
-		case string:
-			lhs, err = castValue(lhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(rhs, CAST_DOUBLE)
-				if err == nil {
-					return lhs.(float64) / cmp.(float64), nil
-				}
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
+	}
+
+	if op.Type == OP_Shl {
+		return op.integerResult(variables, new(big.Int).Lsh(value, uint(count)), target, lhs, rhs)
+	}
+	return op.integerResult(variables, new(big.Int).Rsh(value, uint(count)), target, lhs, rhs)
+}
+
+func (op *Operator) bigIntegerArithmetic(lhs *big.Int, rhs *big.Int) (*big.Int, error) {
+	switch op.Type {
+	case OP_Add:
+		return new(big.Int).Add(lhs, rhs), nil
+	case OP_Sub:
+		return new(big.Int).Sub(lhs, rhs), nil
+	case OP_Mul:
+		return new(big.Int).Mul(lhs, rhs), nil
+	case OP_Div:
+		if rhs.Sign() == 0 {
+			return nil, errors.New("Division by zero")
 		}
-	case string:
-		switch rhs.(type) {
-		case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-			rhs, err = castValue(rhs, CAST_INT)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_INT)
-				if err == nil {
-					return lhs.(int64) / cmp.(int64), nil
-				}
-			}
-		case float32, float64:
-			rhs, err = castValue(rhs, CAST_DOUBLE)
-			if err == nil {
-				cmp, err := castValue(lhs, CAST_DOUBLE)
-				if err != nil {
-					return lhs.(float64) / cmp.(float64), nil
-				}
-			}
-		default:
-			return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(rhs))
+		return new(big.Int).Quo(lhs, rhs), nil
+	case OP_Mod:
+		if rhs.Sign() == 0 {
+			return nil, errors.New("Division by zero")
 		}
+		return new(big.Int).Rem(lhs, rhs), nil
+	default:
+		return nil, fmt.Errorf("Operator '%v' not supported for integers", op.Type)
+	}
+}
+
+func (op *Operator) floatArithmetic(lhs float64, rhs float64) (float64, error) {
+	switch op.Type {
+	case OP_Add:
+		return lhs + rhs, nil
+	case OP_Sub:
+		return lhs - rhs, nil
+	case OP_Mul:
+		return lhs * rhs, nil
+	case OP_Div:
+		return lhs / rhs, nil
+	case OP_Mod:
+		return math.Mod(lhs, rhs), nil
 	default:
-		return false, fmt.Errorf("Comparision not supported for '%v'", reflect.ValueOf(lhs))
+		return 0, fmt.Errorf("Operator '%v' not supported for floats", op.Type)
 	}
+}
 
-	return false, err
+func (op *Operator) decimalArithmetic(lhs Decimal, rhs Decimal) (interface{}, error) {
+	switch op.Type {
+	case OP_Add:
+		return lhs.Add(rhs), nil
+	case OP_Sub:
+		return lhs.Sub(rhs), nil
+	case OP_Mul:
+		return lhs.Mul(rhs), nil
+	case OP_Div:
+		return lhs.Div(rhs)
+	case OP_Mod:
+		return lhs.Mod(rhs)
+	default:
+		return nil, fmt.Errorf("Operator '%v' not supported for decimals", op.Type)
+	}
 }
@@ -0,0 +1,20 @@
+package scripts
+
+// Options options which control how scripts are executed
+type Options struct {
+
+	// PromoteBigIntegers determines whether integer arithmetic which overflows the type of
+	// the operation results in a long or a big integer instead of wrapping around. Results
+	// of int and uint operations are promoted to long, results exceeding 64 bits to big
+	// integers.
+	PromoteBigIntegers bool
+
+	// CheckedArithmetic determines whether integer arithmetic which overflows the type of
+	// the operation results in an OverflowError instead of wrapping around. If
+	// PromoteBigIntegers is enabled as well, overflowing results are promoted instead.
+	CheckedArithmetic bool
+
+	// LooseEquality determines whether == and != compare the string representations of
+	// values like earlier versions did instead of comparing them by type
+	LooseEquality bool
+}
@@ -0,0 +1,97 @@
+package scripts
+
+// ScriptAdder can be implemented by host types to support the + operator
+type ScriptAdder interface {
+	ScriptAdd(other interface{}) (interface{}, error)
+}
+
+// ScriptRightAdder can be implemented by host types to support the + operator with the host
+// value as right operand like 2+money
+type ScriptRightAdder interface {
+	ScriptAddRight(other interface{}) (interface{}, error)
+}
+
+// ScriptSubtracter can be implemented by host types to support the binary - operator
+type ScriptSubtracter interface {
+	ScriptSub(other interface{}) (interface{}, error)
+}
+
+// ScriptMultiplier can be implemented by host types to support the * operator
+type ScriptMultiplier interface {
+	ScriptMul(other interface{}) (interface{}, error)
+}
+
+// ScriptRightMultiplier can be implemented by host types to support the * operator with the
+// host value as right operand like 2*money
+type ScriptRightMultiplier interface {
+	ScriptMulRight(other interface{}) (interface{}, error)
+}
+
+// ScriptDivider can be implemented by host types to support the / operator
+type ScriptDivider interface {
+	ScriptDiv(other interface{}) (interface{}, error)
+}
+
+// ScriptModulator can be implemented by host types to support the % operator
+type ScriptModulator interface {
+	ScriptMod(other interface{}) (interface{}, error)
+}
+
+// ScriptNegater can be implemented by host types to support the unary - operator
+type ScriptNegater interface {
+	ScriptNeg() (interface{}, error)
+}
+
+// overloadedArithmetic evaluates an arithmetic operator using the operator methods of host types
+//
+// The left operand is asked first. If it doesn't implement the operator the right operand
+// is asked using ScriptRightAdder or ScriptRightMultiplier, so operands are never swapped.
+// Adding a string to a host value concatenates both instead.
+//
+// **Returns**
+//   result of the operation, whether an operand implemented the operator and any error
+func (op *Operator) overloadedArithmetic(lhs interface{}, rhs interface{}) (interface{}, bool, error) {
+	switch op.Type {
+	case OP_Add:
+		_, lhsisstring := lhs.(string)
+		_, rhsisstring := rhs.(string)
+		if lhsisstring || rhsisstring {
+			return nil, false, nil
+		}
+
+		if adder, ok := lhs.(ScriptAdder); ok {
+			result, err := adder.ScriptAdd(rhs)
+			return result, true, err
+		}
+		if adder, ok := rhs.(ScriptRightAdder); ok {
+			result, err := adder.ScriptAddRight(lhs)
+			return result, true, err
+		}
+	case OP_Sub:
+		if subtracter, ok := lhs.(ScriptSubtracter); ok {
+			result, err := subtracter.ScriptSub(rhs)
+			return result, true, err
+		}
+	case OP_Mul:
+		if multiplier, ok := lhs.(ScriptMultiplier); ok {
+			result, err := multiplier.ScriptMul(rhs)
+			return result, true, err
+		}
+		if multiplier, ok := rhs.(ScriptRightMultiplier); ok {
+			result, err := multiplier.ScriptMulRight(lhs)
+			return result, true, err
+		}
+	case OP_Div:
+		if divider, ok := lhs.(ScriptDivider); ok {
+			result, err := divider.ScriptDiv(rhs)
+			return result, true, err
+		}
+	case OP_Mod:
+		if modulator, ok := lhs.(ScriptModulator); ok {
+			result, err := modulator.ScriptMod(rhs)
+			return result, true, err
+		}
+	}
+
+	return nil, false, nil
+}
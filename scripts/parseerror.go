@@ -0,0 +1,20 @@
+package scripts
+
+import "fmt"
+
+// ParseError error with information about where in the script it occured
+type ParseError struct {
+	Message  string
+	Position int
+}
+
+// Error returns the error message including the script position
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s at index %d", err.Message, err.Position)
+}
+
+func newParseError(position int, format string, args ...interface{}) *ParseError {
+	return &ParseError{
+		Message:  fmt.Sprintf(format, args...),
+		Position: position}
+}
@@ -0,0 +1,327 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// MaxRangeValues maximum number of values a range is enumerated to
+var MaxRangeValues = 1000000
+
+// Index position in a string or array counted from the end like ^1
+type Index struct {
+	Value   int
+	FromEnd bool
+}
+
+// Range range of values between two bounds
+//
+// Bounds which are null are open, so ..5 contains all values up to 5. The end of the range
+// is included unless the range is exclusive like 1..<5.
+type Range struct {
+	Start     interface{}
+	End       interface{}
+	Exclusive bool
+}
+
+// Contains determines whether a value is contained in the range
+func (r Range) Contains(value interface{}) (bool, error) {
+	if r.Start != nil {
+		comparision, ordered, err := compareValues(value, r.Start)
+		if err != nil || !ordered || comparision < 0 {
+			return false, err
+		}
+	}
+
+	if r.End != nil {
+		comparision, ordered, err := compareValues(value, r.End)
+		if err != nil || !ordered {
+			return false, err
+		}
+		if comparision > 0 || r.Exclusive && comparision == 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// Values returns all integers contained in the range
+//
+// Ranges containing more than MaxRangeValues values are not enumerated.
+func (r Range) Values() ([]interface{}, error) {
+	starttype := numericTypeOf(r.Start)
+	endtype := numericTypeOf(r.End)
+	if !isIntegralType(starttype) || !isIntegralType(endtype) {
+		return nil, fmt.Errorf("Range '%v' can not be enumerated since its bounds are no integers", r)
+	}
+
+	target := promoteNumericTypes(starttype, endtype)
+	current, _ := toBigInteger(r.Start)
+	end, _ := toBigInteger(r.End)
+	if !r.Exclusive {
+		end = new(big.Int).Add(end, big.NewInt(1))
+	}
+
+	if count := new(big.Int).Sub(end, current); count.Cmp(big.NewInt(int64(MaxRangeValues))) > 0 {
+		return nil, fmt.Errorf("Range '%v' contains more than %d values", r, MaxRangeValues)
+	}
+
+	values := []interface{}{}
+	for value := new(big.Int).Set(current); value.Cmp(end) < 0; value.Add(value, big.NewInt(1)) {
+		converted, _ := integerResult(new(big.Int).Set(value), target)
+		values = append(values, converted)
+	}
+	return values, nil
+}
+
+// String returns the range in script notation
+func (r Range) String() string {
+	var builder strings.Builder
+	if r.Start != nil {
+		builder.WriteString(fmt.Sprintf("%v", r.Start))
+	}
+	builder.WriteString("..")
+	if r.Exclusive {
+		builder.WriteString("<")
+	}
+	if r.End != nil {
+		builder.WriteString(fmt.Sprintf("%v", r.End))
+	}
+	return builder.String()
+}
+
+// String returns the index in script notation
+func (index Index) String() string {
+	if index.FromEnd {
+		return fmt.Sprintf("^%d", index.Value)
+	}
+	return fmt.Sprintf("%d", index.Value)
+}
+
+// rangeValue evaluates the range operators .. and ..<
+func (op *Operator) rangeValue(variables *Variables) (interface{}, error) {
+	start, end, err := op.operands(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return Range{Start: start, End: end, Exclusive: op.Type == OP_RangeExclusive}, nil
+}
+
+// fromEnd evaluates the index operator ^ which counts an index from the end
+func (op *Operator) fromEnd(variables *Variables) (interface{}, error) {
+	value, err := op.LHS.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := toIndex(value)
+	if err != nil {
+		return nil, err
+	}
+	if index.FromEnd || index.Value < 0 {
+		return nil, fmt.Errorf("Invalid index '^%v'", value)
+	}
+
+	return Index{Value: index.Value, FromEnd: true}, nil
+}
+
+// contains evaluates the operator in
+//
+// Values are contained in ranges if they lie between its bounds, in strings if they are a
+// substring, in arrays if an element is equal and in maps if they are a key.
+func (op *Operator) contains(variables *Variables) (bool, error) {
+	value, collection, err := op.operands(variables)
+	if err != nil {
+		return false, err
+	}
+
+	switch v := collection.(type) {
+	case Range:
+		return v.Contains(value)
+	case string:
+		return strings.Contains(v, fmt.Sprintf("%v", value)), nil
+	case nil:
+		return false, nil
+	}
+
+	reflected := reflect.ValueOf(collection)
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < reflected.Len(); i++ {
+			equal, err := valuesEqual(value, reflected.Index(i).Interface())
+			if err != nil || equal {
+				return equal, err
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		for _, key := range reflected.MapKeys() {
+			equal, err := valuesEqual(value, key.Interface())
+			if err != nil || equal {
+				return equal, err
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("Operator 'in' not supported for '%v'", collection)
+	}
+}
+
+// toIndex converts an integer or an Index to an Index
+func toIndex(value interface{}) (Index, error) {
+	if index, ok := value.(Index); ok {
+		return index, nil
+	}
+
+	if !isIntegralType(numericTypeOf(value)) {
+		return Index{}, fmt.Errorf("'%v' is not a valid index", value)
+	}
+
+	integer, _ := toBigInteger(value)
+	if !integer.IsInt64() || integer.Int64() != int64(int(integer.Int64())) {
+		return Index{}, fmt.Errorf("Index '%v' out of range", value)
+	}
+	return Index{Value: int(integer.Int64())}, nil
+}
+
+// offset determines the offset of the index in a sequence of the specified length
+func (index Index) offset(length int) int {
+	if index.FromEnd {
+		return length - index.Value
+	}
+	return index.Value
+}
+
+// bounds determines the start and end offset of the range in a sequence of the specified length
+//
+// **Returns**
+//   start offset, end offset (exclusive) and any error
+func (r Range) bounds(length int) (int, int, error) {
+	start := 0
+	if r.Start != nil {
+		index, err := toIndex(r.Start)
+		if err != nil {
+			return 0, 0, err
+		}
+		start = index.offset(length)
+	}
+
+	end := length
+	if r.End != nil {
+		index, err := toIndex(r.End)
+		if err != nil {
+			return 0, 0, err
+		}
+		end = index.offset(length)
+		if !r.Exclusive {
+			end++
+		}
+	}
+
+	if start < 0 || end > length || start > end {
+		return 0, 0, fmt.Errorf("Range '%v' out of bounds for length %d", r, length)
+	}
+	return start, end, nil
+}
+
+// Indexer accesses an element or a slice of a string, an array or a map
+//
+// Strings are indexed by characters, indexing with a range returns a substring or a
+// slice of the array.
+//
+// Keys of maps are converted to the key type of the map like arguments of host functions.
+type Indexer struct {
+	Host        Token
+	Index       Token
+	conversions *Conversions
+}
+
+// Execute returns the element or slice of the host
+func (indexer *Indexer) Execute(variables *Variables) (interface{}, error) {
+	host, err := indexer.Host.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+	if host == nil {
+		return nil, errors.New("Null reference")
+	}
+
+	key, err := indexer.Index.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	if text, ok := host.(string); ok {
+		characters := []rune(text)
+		if r, ok := key.(Range); ok {
+			start, end, err := r.bounds(len(characters))
+			if err != nil {
+				return nil, err
+			}
+			return string(characters[start:end]), nil
+		}
+
+		offset, err := elementOffset(key, len(characters))
+		if err != nil {
+			return nil, err
+		}
+		return Char(characters[offset]), nil
+	}
+
+	reflected := reflect.ValueOf(host)
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array:
+		if r, ok := key.(Range); ok {
+			start, end, err := r.bounds(reflected.Len())
+			if err != nil {
+				return nil, err
+			}
+			if reflected.Kind() == reflect.Array {
+				array, _ := castArray(host)
+				return array[start:end], nil
+			}
+			return reflected.Slice(start, end).Interface(), nil
+		}
+
+		offset, err := elementOffset(key, reflected.Len())
+		if err != nil {
+			return nil, err
+		}
+		return reflected.Index(offset).Interface(), nil
+	case reflect.Map:
+		if key == nil {
+			return nil, fmt.Errorf("'%v' is not a valid key for '%v'", key, reflected.Type())
+		}
+		mapkey, err := indexer.conversions.convertArgument(key, reflected.Type().Key())
+		if err != nil {
+			return nil, fmt.Errorf("'%v' is not a valid key for '%v'", key, reflected.Type())
+		}
+
+		value := reflected.MapIndex(mapkey)
+		if !value.IsValid() {
+			return nil, fmt.Errorf("Key '%v' not found", key)
+		}
+		return value.Interface(), nil
+	default:
+		return nil, fmt.Errorf("'%v' can not be indexed", host)
+	}
+}
+
+// elementOffset determines the offset of an element in a sequence of the specified length
+func elementOffset(key interface{}, length int) (int, error) {
+	index, err := toIndex(key)
+	if err != nil {
+		return 0, err
+	}
+
+	offset := index.offset(length)
+	if offset < 0 || offset >= length {
+		return 0, fmt.Errorf("Index '%v' out of bounds for length %d", index, length)
+	}
+	return offset, nil
+}
@@ -2,6 +2,9 @@ package scripts
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"math/big"
 	"sort"
 	"strconv"
 	"strings"
@@ -9,178 +12,316 @@ import (
 
 // Parser parser used to parse expressions
 type Parser struct {
-	operators *OperatorTree
+	operators   *OperatorTree
+	conversions *Conversions
+	types       *Types
+	namespaces  *Namespaces
+	modules     *moduleCache
+	importing   []string
 }
 
 // NewParser creates a new expression parser
 func NewParser(operators *OperatorTree) *Parser {
-	return &Parser{operators: operators}
+	return &Parser{
+		operators:   operators,
+		conversions: NewConversions(),
+		types:       NewTypes(),
+		namespaces:  NewNamespaces(),
+		modules:     &moduleCache{modules: make(map[string]*StatementBlock)}}
+}
+
+// Conversions get registry of conversions available to parsed scripts
+func (parser *Parser) Conversions() *Conversions {
+	return parser.conversions
+}
+
+// Types get registry of host types parsed scripts can construct
+func (parser *Parser) Types() *Types {
+	return parser.types
+}
+
+// Namespaces get registry of namespaces containing host functions and constants
+//
+// Namespaces have to be registered before parsing scripts using them.
+func (parser *Parser) Namespaces() *Namespaces {
+	return parser.namespaces
+}
+
+// SetModuleLoader set loader used to load modules imported by parsed scripts
+func (parser *Parser) SetModuleLoader(loader ModuleLoader) {
+	parser.modules.lock.Lock()
+	defer parser.modules.lock.Unlock()
+	parser.modules.loader = loader
+	parser.modules.modules = make(map[string]*StatementBlock)
 }
 
 // Parse parses a script expression
 func (parser *Parser) Parse(data string) (Token, error) {
 	index := 0
-	return parser.parseStatementBlock(nil, &data, &index, true)
+	block, err := parser.parseStatementBlock(nil, &data, &index, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
 }
 
 func parseCharacter(data *string, index *int) (Token, error) {
-	character := (*data)[*index]
+	if *index >= len(*data) {
+		return nil, errors.New("Character Literal not terminated")
+	}
+
+	character, size := readRune(data, *index)
+	*index += size
 	if character == '\\' {
-		(*index)++
-		character = parseSpecialCharacter((*data)[*index])
+		var err error
+		character, err = parseEscapeSequence(data, index)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	(*index)++
-	if (*data)[*index] != '\'' {
+	if *index >= len(*data) || (*data)[*index] != '\'' {
 		return nil, errors.New("Character Literal not terminated")
 	}
 
 	(*index)++
-	return &Value{Value: character}, nil
+	return &Value{Value: Char(character)}, nil
 }
 
 func parseLiteral(data *string, index *int) (Token, error) {
 	var literal strings.Builder
 
-	for ; *index < len(*data); (*index)++ {
-		character := (*data)[*index]
+	for *index < len(*data) {
+		character, size := readRune(data, *index)
+		*index += size
+
 		switch character {
 		case '"':
-			(*index)++
 			return &Value{Value: literal.String()}, nil
 		case '\\':
-			(*index)++
-			literal.WriteByte(parseSpecialCharacter((*data)[*index]))
+			character, err := parseEscapeSequence(data, index)
+			if err != nil {
+				return nil, err
+			}
+			literal.WriteRune(character)
 		default:
-			literal.WriteByte(character)
+			literal.WriteRune(character)
 		}
 	}
 
 	return nil, errors.New("Literal not terminated")
 }
 
-func parseNumber(token string) (interface{}, error) {
-	token = strings.ToLower(token)
-	if strings.HasPrefix(token, "0x") {
-		if strings.HasSuffix(token, "ul") {
-			return strconv.ParseUint(token[2:len(token)-2], 16, 64)
-		}
-		if strings.HasSuffix(token, "l") {
-			return strconv.ParseInt(token[2:len(token)-1], 16, 64)
-		}
-		if strings.HasSuffix(token, "us") {
-			return strconv.ParseUint(token[2:len(token)-2], 16, 16)
-		}
-		if strings.HasSuffix(token, "s") {
-			return strconv.ParseInt(token[2:len(token)-1], 16, 16)
-		}
-		if strings.HasSuffix(token, "sb") {
-			return strconv.ParseUint(token[2:len(token)-2], 16, 8)
-		}
-		if strings.HasSuffix(token, "b") {
-			return strconv.ParseInt(token[2:len(token)-1], 16, 8)
-		}
-		if strings.HasSuffix(token, "u") {
-			return strconv.ParseUint(token[2:len(token)-1], 16, 32)
+// parseVerbatimLiteral parses a literal in which backslashes are not treated as escape
+// characters and quotes are escaped by doubling them
+func parseVerbatimLiteral(data *string, index *int) (Token, error) {
+	var literal strings.Builder
+
+	for *index < len(*data) {
+		character, size := readRune(data, *index)
+		*index += size
+
+		if character == '"' {
+			if *index >= len(*data) || (*data)[*index] != '"' {
+				return &Value{Value: literal.String()}, nil
+			}
+			(*index)++
 		}
-		return strconv.ParseInt(token[2:], 16, 32)
+
+		literal.WriteRune(character)
 	}
 
-	if strings.HasPrefix(token, "0o") {
-		if strings.HasSuffix(token, "ul") {
-			return strconv.ParseUint(token[2:len(token)-2], 8, 64)
-		}
-		if strings.HasSuffix(token, "l") {
-			return strconv.ParseInt(token[2:len(token)-1], 8, 64)
-		}
-		if strings.HasSuffix(token, "us") {
-			return strconv.ParseUint(token[2:len(token)-2], 8, 16)
-		}
-		if strings.HasSuffix(token, "s") {
-			return strconv.ParseInt(token[2:len(token)-1], 8, 16)
-		}
-		if strings.HasSuffix(token, "sb") {
-			return strconv.ParseUint(token[2:len(token)-2], 8, 8)
-		}
-		if strings.HasSuffix(token, "b") {
-			return strconv.ParseInt(token[2:len(token)-1], 8, 8)
-		}
-		if strings.HasSuffix(token, "u") {
-			return strconv.ParseUint(token[2:len(token)-1], 8, 32)
-		}
-		return strconv.ParseInt(token[2:], 8, 32)
+	return nil, errors.New("Literal not terminated")
+}
+
+// integerSuffix type suffix of an integer literal
+type integerSuffix struct {
+	suffix   string
+	unsigned bool
+	bits     int
+}
+
+// integerSuffixes suffixes of integer literals, suffixes ending with another suffix have to
+// be checked first
+var integerSuffixes = []integerSuffix{
+	{suffix: "ul", unsigned: true, bits: 64},
+	{suffix: "l", unsigned: false, bits: 64},
+	{suffix: "us", unsigned: true, bits: 16},
+	{suffix: "s", unsigned: false, bits: 16},
+	{suffix: "sb", unsigned: true, bits: 8},
+	{suffix: "b", unsigned: false, bits: 8},
+	{suffix: "u", unsigned: true, bits: 32},
+}
+
+func parseNumber(token string) (interface{}, error) {
+	token, err := removeDigitSeparators(strings.ToLower(token))
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.HasPrefix(token, "0b") {
-		if strings.HasSuffix(token, "ul") {
-			return strconv.ParseUint(token[2:len(token)-2], 2, 64)
-		}
-		if strings.HasSuffix(token, "l") {
-			return strconv.ParseInt(token[2:len(token)-1], 2, 64)
-		}
-		if strings.HasSuffix(token, "us") {
-			return strconv.ParseUint(token[2:len(token)-2], 2, 16)
-		}
-		if strings.HasSuffix(token, "s") {
-			return strconv.ParseInt(token[2:len(token)-1], 2, 16)
-		}
-		if strings.HasSuffix(token, "sb") {
-			return strconv.ParseUint(token[2:len(token)-2], 2, 8)
-		}
-		if strings.HasSuffix(token, "b") {
-			return strconv.ParseInt(token[2:len(token)-1], 2, 8)
+	if isDurationLiteral(token) {
+		return parseDuration(token)
+	}
+
+	if strings.HasSuffix(token, "n") {
+		return parseBigInteger(token[:len(token)-1])
+	}
+
+	base := 0
+	switch {
+	case strings.HasPrefix(token, "0x"):
+		base = 16
+	case strings.HasPrefix(token, "0o"):
+		base = 8
+	case strings.HasPrefix(token, "0b"):
+		base = 2
+	}
+
+	if base != 0 {
+		for _, suffix := range integerSuffixes {
+			// b is a hex digit, so byte suffixes are not supported for hex literals
+			if base == 16 && strings.HasSuffix(suffix.suffix, "b") {
+				continue
+			}
+			if strings.HasSuffix(token, suffix.suffix) {
+				return parseTypedInteger(token[2:len(token)-len(suffix.suffix)], base, suffix)
+			}
 		}
-		if strings.HasSuffix(token, "u") {
-			return strconv.ParseUint(token[2:len(token)-1], 2, 32)
+		return parseInteger(token[2:], base)
+	}
+
+	for _, suffix := range integerSuffixes {
+		if strings.HasSuffix(token, suffix.suffix) {
+			return parseTypedInteger(token[:len(token)-len(suffix.suffix)], 10, suffix)
 		}
-		return strconv.ParseInt(token[2:], 2, 32)
 	}
 
-	if strings.HasSuffix(token, "ul") {
-		return strconv.ParseUint(token, 10, 64)
+	dotcount := strings.Count(token, ".")
+	if dotcount > 1 {
+		// if no format triggers here this token is used as a string
+		return &Value{Value: token}, nil
 	}
-	if strings.HasSuffix(token, "l") {
-		return strconv.ParseInt(token, 10, 64)
+
+	if strings.HasSuffix(token, "d") {
+		return ParseDecimal(token[:len(token)-1])
 	}
-	if strings.HasSuffix(token, "us") {
-		return strconv.ParseUint(token, 10, 16)
+	if strings.HasSuffix(token, "f") {
+		value, err := strconv.ParseFloat(token[:len(token)-1], 32)
+		return float32(value), err
 	}
-	if strings.HasSuffix(token, "s") {
-		return strconv.ParseInt(token, 10, 16)
+	if dotcount == 0 && !strings.ContainsRune(token, 'e') {
+		return parseInteger(token, 10)
 	}
-	if strings.HasSuffix(token, "sb") {
-		return strconv.ParseUint(token, 10, 8)
+
+	return strconv.ParseFloat(token, 64)
+}
+
+// removeDigitSeparators removes underscores used to group digits of a number literal
+//
+// Separators are only valid between digits, so '1_000' is valid while '_1', '1_' or '1_.5' are not.
+func removeDigitSeparators(token string) (string, error) {
+	if !strings.ContainsRune(token, '_') {
+		return token, nil
 	}
-	if strings.HasSuffix(token, "b") {
-		return strconv.ParseInt(token, 10, 8)
+
+	isdigit := func(character byte) bool {
+		return character >= '0' && character <= '9'
 	}
-	if strings.HasSuffix(token, "u") {
-		return strconv.ParseUint(token, 10, 32)
+	start := 0
+	if strings.HasPrefix(token, "0x") {
+		isdigit = func(character byte) bool {
+			return character >= '0' && character <= '9' || character >= 'a' && character <= 'f'
+		}
+		start = 2
+	} else if strings.HasPrefix(token, "0o") || strings.HasPrefix(token, "0b") {
+		start = 2
 	}
 
-	dotcount := 0
+	var builder strings.Builder
 	for i := 0; i < len(token); i++ {
-		if token[i] == '.' {
-			dotcount++
+		if token[i] != '_' {
+			builder.WriteByte(token[i])
+			continue
+		}
+
+		previous := i - 1
+		for previous >= start && token[previous] == '_' {
+			previous--
+		}
+		next := i + 1
+		for next < len(token) && token[next] == '_' {
+			next++
+		}
+
+		if previous < start || !isdigit(token[previous]) || next >= len(token) || !isdigit(token[next]) {
+			return "", fmt.Errorf("Invalid digit separator in '%s'", token)
 		}
 	}
 
-	switch dotcount {
-	case 0:
-		return strconv.ParseInt(token, 10, 32)
-	case 1:
-		if strings.HasSuffix(token, "d") {
-			// this would be decimal but currently we don't use decimals
-			return strconv.ParseFloat(token[:len(token)-1], 64)
+	return builder.String(), nil
+}
+
+// parseInteger parses an integer literal without suffix
+//
+// Like in C# the literal gets the first type of int, uint, long and ulong which can
+// represent its value, literals exceeding 64 bit result in a big integer.
+func parseInteger(digits string, base int) (interface{}, error) {
+	if value, err := strconv.ParseInt(digits, base, 32); err == nil {
+		return int32(value), nil
+	}
+	if value, err := strconv.ParseUint(digits, base, 32); err == nil {
+		return uint32(value), nil
+	}
+	if value, err := strconv.ParseInt(digits, base, 64); err == nil {
+		return value, nil
+	}
+
+	value, err := strconv.ParseUint(digits, base, 64)
+	if err != nil && errors.Is(err, strconv.ErrRange) {
+		integer, ok := new(big.Int).SetString(digits, base)
+		if !ok {
+			return nil, err
+		}
+		return integer, nil
+	}
+	return value, err
+}
+
+// parseTypedInteger parses an integer literal with a type suffix
+func parseTypedInteger(digits string, base int, suffix integerSuffix) (interface{}, error) {
+	if suffix.unsigned {
+		value, err := strconv.ParseUint(digits, base, suffix.bits)
+		if err != nil {
+			return nil, err
 		}
-		if strings.HasSuffix(token, "f") {
-			return strconv.ParseFloat(token[:len(token)-1], 32)
+
+		switch suffix.bits {
+		case 8:
+			return uint8(value), nil
+		case 16:
+			return uint16(value), nil
+		case 32:
+			return uint32(value), nil
+		default:
+			return value, nil
 		}
+	}
+
+	value, err := strconv.ParseInt(digits, base, suffix.bits)
+	if err != nil {
+		return nil, err
+	}
 
-		return strconv.ParseFloat(token, 64)
+	switch suffix.bits {
+	case 8:
+		return int8(value), nil
+	case 16:
+		return int16(value), nil
+	case 32:
+		return int32(value), nil
 	default:
-		// if no format triggers here this token is used as a string
-		return &Value{Value: token}, nil
+		return value, nil
 	}
 }
 
@@ -232,7 +373,7 @@ func (parser *Parser) analyseToken(token string, data *string, index *int, start
 		return nil, errors.New("Token expected")
 	}
 
-	if token[0] >= 0x30 && token[0] <= 0x39 {
+	if token[0] >= 0x30 && token[0] <= 0x39 || token[0] == '.' {
 		number, err := parseNumber(token)
 		if err != nil {
 			return nil, err
@@ -242,22 +383,92 @@ func (parser *Parser) analyseToken(token string, data *string, index *int, start
 	}
 
 	if startofstatement {
-		// TODO: parse possible control tokens
+		switch token {
+		case "throw":
+			position := *index - len(token)
+			value, err := parser.parseTokenBlock(nil, data, index, false)
+			if err != nil {
+				return nil, err
+			}
+
+			return &Throw{
+				Value:    value,
+				position: position}, nil
+		case "try":
+			return parser.parseTry(data, index)
+		case "var", "let", "const":
+			return parser.parseDeclaration(token, data, index)
+		case "function", "export":
+			// keywords are only used as such if followed by a name
+			next := *index
+			skipWhiteSpaces(data, &next)
+			if len(readIdentifier(data, next)) > 0 {
+				if token == "function" {
+					return parser.parseFunction(data, index)
+				}
+				return parser.parseExport(data, index)
+			}
+		case "import":
+			if peek(data, *index) == '"' {
+				return parser.parseImport(data, index)
+			}
+		}
 	}
 
-	switch token {
-	case "bool", "int", "float", "double", "string":
+	// type names which are not followed by a parameter are used as variable names
+	if peek(data, *index) == '(' && parser.conversions.IsTargetType(token) {
 		parameter, err := parser.parseSingleParameter(data, index)
 		if err != nil {
 			return nil, err
 		}
 
 		return &Cast{
-			TargetType: token,
-			Data:       parameter}, nil
+			TargetType:  token,
+			Data:        parameter,
+			conversions: parser.conversions}, nil
+	}
+
+	if token == "new" {
+		// new is only a keyword if followed by a type name
+		next := *index
+		skipWhiteSpaces(data, &next)
+		if len(readIdentifier(data, next)) > 0 {
+			return parser.parseNew(data, index)
+		}
+	}
+
+	if token == "match" && peek(data, *index) == '(' {
+		return parser.parseMatch(data, index)
+	}
+
+	if token == "typeof" {
+		parameter, err := parser.parseSingleParameter(data, index)
+		if err != nil {
+			return nil, err
+		}
+
+		return &TypeOf{
+			Data:        parameter,
+			conversions: parser.conversions}, nil
+	}
+
+	switch token {
+	case "checked", "unchecked":
+		parameter, err := parser.parseSingleParameter(data, index)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Checked{
+			Enabled: token == "checked",
+			Data:    parameter}, nil
 	}
 
 	switch token {
+	case "NaN":
+		return &Value{Value: math.NaN()}, nil
+	case "Infinity":
+		return &Value{Value: math.Inf(1)}, nil
 	case "true":
 		return &Value{Value: true}, nil
 	case "false":
@@ -266,7 +477,32 @@ func (parser *Parser) analyseToken(token string, data *string, index *int, start
 		return &Value{Value: nil}, nil
 	}
 
-	return &Variable{Name: token}, nil
+	if peek(data, *index) == '(' {
+		parameters, err := parser.parseParameters(data, index)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Call{
+			Name:        token,
+			Parameters:  parameters,
+			conversions: parser.conversions}, nil
+	}
+
+	return &Variable{
+		Name:      token,
+		namespace: parser.namespace(token)}, nil
+}
+
+// namespace returns the namespace or enum with the specified name or nil if none exists
+func (parser *Parser) namespace(name string) MemberHost {
+	if namespace := parser.namespaces.root(name); namespace != nil {
+		return namespace
+	}
+	if enum, ok := parser.conversions.enums[name]; ok {
+		return enum
+	}
+	return nil
 }
 
 func (parser *Parser) parseToken(data *string, index *int, startofstatement bool) (Token, error) {
@@ -280,22 +516,41 @@ func (parser *Parser) parseToken(data *string, index *int, startofstatement bool
 		case '"':
 			(*index)++
 			return parseLiteral(data, index)
+		case '@':
+			if strings.HasPrefix((*data)[*index:], "@\"") {
+				*index += 2
+				return parseVerbatimLiteral(data, index)
+			}
+			if strings.HasPrefix((*data)[*index:], "@$\"") {
+				*index += 3
+				return parser.parseInterpolation(data, index, true)
+			}
 		case '\'':
+			(*index)++
 			return parseCharacter(data, index)
+		case '#':
+			(*index)++
+			return parseDateLiteral(data, index)
 		}
 	}
 
 	var tokenname strings.Builder
-	for ; *index < len(*data); (*index)++ {
-		character := (*data)[*index]
-		if character >= 0x30 && character <= 0x39 || character >= 0x41 && character <= 0x5A || character >= 0x61 && character <= 0x7A || parsenumber && character == '.' {
-			tokenname.WriteByte(character)
+	for *index < len(*data) {
+		character, size := readRune(data, *index)
+		if isIdentifierCharacter(character) || parsenumber && (character == '.' && !isRangeOperator(data, *index) || isExponentSign(data, *index)) {
+			tokenname.WriteRune(character)
 		} else if character == '"' || character == '\\' {
-			(*index)++
-			tokenname.WriteByte(parseSpecialCharacter((*data)[*index]))
+			*index += size
+			character, err := parseEscapeSequence(data, index)
+			if err != nil {
+				return nil, err
+			}
+			tokenname.WriteRune(character)
+			continue
 		} else {
 			return parser.analyseToken(tokenname.String(), data, index, startofstatement)
 		}
+		*index += size
 	}
 
 	if tokenname.Len() > 0 {
@@ -305,21 +560,33 @@ func (parser *Parser) parseToken(data *string, index *int, startofstatement bool
 	return &Value{}, nil
 }
 
-func (parser *Parser) parseInterpolation(data *string, index *int) (Token, error) {
+// parseInterpolation parses an interpolated string
+//
+// **Parameters**
+//   data:     script data
+//   index:    index of the first character after the opening quote
+//   verbatim: determines whether the string is verbatim, so backslashes are no escape characters
+func (parser *Parser) parseInterpolation(data *string, index *int, verbatim bool) (Token, error) {
 	var tokens []Token
 	var literal strings.Builder
 
-	for ; *index < len(*data); (*index)++ {
-		character := (*data)[*index]
+	for *index < len(*data) {
+		character, size := readRune(data, *index)
+		*index += size
 
 		switch character {
 		case '"':
-			(*index)++
+			if verbatim && *index < len(*data) && (*data)[*index] == '"' {
+				(*index)++
+				literal.WriteRune('"')
+				break
+			}
+
 			tokens = append(tokens, &Value{Value: literal.String()})
 			return &Interpolation{tokens: tokens}, nil
 		case '{':
-			(*index)++
-			if peek(data, *index) == '{' {
+			if *index < len(*data) && (*data)[*index] == '{' {
+				(*index)++
 				literal.WriteRune('{')
 			} else {
 				if literal.Len() > 0 {
@@ -327,26 +594,87 @@ func (parser *Parser) parseInterpolation(data *string, index *int) (Token, error
 					literal.Reset()
 				}
 
-				block, err := parser.parseStatementBlock(nil, data, index, false)
+				hole, err := parser.parseInterpolationHole(data, index)
 				if err != nil {
 					return nil, err
 				}
-				tokens = append(tokens, block)
-
-				// for loop automatically increases index, but index should remain here
-				(*index)--
+				tokens = append(tokens, hole)
 			}
+		case '}':
+			if *index < len(*data) && (*data)[*index] == '}' {
+				(*index)++
+			}
+			literal.WriteRune('}')
 		case '\\':
-			(*index)++
-			literal.WriteByte(parseSpecialCharacter((*data)[*index]))
+			if verbatim {
+				literal.WriteRune(character)
+				break
+			}
+
+			character, err := parseEscapeSequence(data, index)
+			if err != nil {
+				return nil, err
+			}
+			literal.WriteRune(character)
 		default:
-			literal.WriteByte(character)
+			literal.WriteRune(character)
 		}
 	}
 
 	return nil, errors.New("Literal not terminated")
 }
 
+// parseInterpolationHole parses an expression of an interpolated string with optional
+// alignment and format string like '{value,10:F2}'
+func (parser *Parser) parseInterpolationHole(data *string, index *int) (Token, error) {
+	start := *index
+	value, err := parser.parseTokenBlock(nil, data, index, false)
+	if err != nil {
+		return nil, err
+	}
+
+	format := &Format{value: value}
+	skipWhiteSpaces(data, index)
+	if *index < len(*data) && (*data)[*index] == ',' {
+		(*index)++
+		skipWhiteSpaces(data, index)
+
+		alignmentstart := *index
+		if *index < len(*data) && (*data)[*index] == '-' {
+			(*index)++
+		}
+		for *index < len(*data) && (*data)[*index] >= '0' && (*data)[*index] <= '9' {
+			(*index)++
+		}
+
+		format.alignment, err = strconv.Atoi((*data)[alignmentstart:*index])
+		if err != nil {
+			return nil, newParseError(alignmentstart, "Alignment expected")
+		}
+		skipWhiteSpaces(data, index)
+	}
+
+	if *index < len(*data) && (*data)[*index] == ':' {
+		(*index)++
+		formatstart := *index
+		for *index < len(*data) && (*data)[*index] != '}' {
+			(*index)++
+		}
+		format.format = (*data)[formatstart:*index]
+	}
+
+	if *index >= len(*data) || (*data)[*index] != '}' {
+		return nil, newParseError(start, "Interpolation hole not terminated")
+	}
+	(*index)++
+
+	if format.alignment == 0 && len(format.format) == 0 {
+		return value, nil
+	}
+
+	return format, nil
+}
+
 func (parser *Parser) parseBlock(data *string, index *int) (Token, error) {
 	block, err := parser.parseTokenBlock(nil, data, index, false)
 	if err != nil {
@@ -366,24 +694,38 @@ func (parser *Parser) parseBlock(data *string, index *int) (Token, error) {
 	}, nil
 }
 
-func parseMember(host Token, data *string, index *int) (Token, error) {
+// parseMember parses the member of a host, members followed by parameters are called
+func (parser *Parser) parseMember(host Token, data *string, index *int) (Token, error) {
 	var membername strings.Builder
 
-	for ; *index < len(*data); (*index)++ {
-		character := (*data)[*index]
-		if character >= 0x30 && character <= 0x39 || character >= 0x41 && character <= 0x5A || character >= 0x61 && character <= 0x7A || character == '_' {
-			membername.WriteByte(character)
-			continue
+	for *index < len(*data) {
+		character, size := readRune(data, *index)
+		if !isIdentifierCharacter(character) {
+			break
 		}
 
-		break
+		membername.WriteRune(character)
+		*index += size
+	}
+
+	if membername.Len() == 0 {
+		return nil, errors.New("Membername expected")
 	}
 
-	if membername.Len() > 0 {
-		return &Member{host: host, member: membername.String()}, nil
+	if peek(data, *index) == '(' {
+		parameters, err := parser.parseParameters(data, index)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Call{
+			Name:        membername.String(),
+			Host:        host,
+			Parameters:  parameters,
+			conversions: parser.conversions}, nil
 	}
 
-	return nil, errors.New("Membername expected")
+	return &Member{host: host, member: membername.String()}, nil
 }
 
 func (parser *Parser) parseTokenBlock(parent Token, data *string, index *int, startofstatement bool) (Token, error) {
@@ -398,6 +740,11 @@ func (parser *Parser) parseTokenBlock(parent Token, data *string, index *int, st
 	for *index < len(*data) && !done {
 		switch (*data)[*index] {
 		case '=', '!', '~', '<', '>', '/', '+', '*', '-', '%', '&', '|', '^':
+			if strings.HasPrefix((*data)[*index:], "=>") {
+				done = true
+				break
+			}
+
 			operator, err := parser.operators.ParseOperator(data, index)
 			if err != nil {
 				return nil, err
@@ -409,14 +756,18 @@ func (parser *Parser) parseTokenBlock(parent Token, data *string, index *int, st
 				break
 			}
 
-			if operator.Type == OP_Sub {
+			if operator.Type == OP_Sub || operator.Type == OP_BitXor {
 				var isop bool
 				if len(tokens) > 0 {
 					_, isop = tokens[len(tokens)-1].(*Operator)
 				}
 
 				if len(tokens) == 0 || isop {
-					operator = &Operator{Class: OP_PreUnary, Type: OP_Neg}
+					if operator.Type == OP_Sub {
+						operator = &Operator{Class: OP_PreUnary, Type: OP_Neg}
+					} else {
+						operator = &Operator{Class: OP_PreUnary, Type: OP_FromEnd}
+					}
 				}
 			}
 
@@ -427,7 +778,28 @@ func (parser *Parser) parseTokenBlock(parent Token, data *string, index *int, st
 				concat = true
 			}
 		case '.':
-			member, err := parseMember(tokens[len(tokens)-1], data, index)
+			if isNumberStart(data, *index) && (len(tokens) == 0 || isOperator(tokens[len(tokens)-1])) {
+				token, err := parser.parseToken(data, index, startofstatement)
+				if err != nil {
+					return nil, err
+				}
+
+				tokens = append(tokens, token)
+				break
+			}
+
+			if isRangeOperator(data, *index) {
+				tokens, operators = parser.parseRangeOperator(tokens, operators, data, index)
+				concat = true
+				break
+			}
+
+			if len(tokens) == 0 {
+				return nil, errors.New("Member access without host")
+			}
+
+			(*index)++
+			member, err := parser.parseMember(tokens[len(tokens)-1], data, index)
 			if err != nil {
 				return nil, err
 			}
@@ -439,9 +811,13 @@ func (parser *Parser) parseTokenBlock(parent Token, data *string, index *int, st
 			}
 
 			(*index)++
-			if peek(data, *index) == '"' {
+			verbatim := strings.HasPrefix((*data)[*index:], "@\"")
+			if verbatim || peek(data, *index) == '"' {
+				if verbatim {
+					(*index)++
+				}
 				(*index)++
-				str, err := parser.parseInterpolation(data, index)
+				str, err := parser.parseInterpolation(data, index, verbatim)
 				if err != nil {
 					return nil, err
 				}
@@ -469,23 +845,66 @@ func (parser *Parser) parseTokenBlock(parent Token, data *string, index *int, st
 			tokens = append(tokens, block)
 			concat = false
 		case '[':
-			// TODO: parse indexer
-			return nil, errors.New("Array indexer not yet implemented")
+			if len(tokens) == 0 || isOperator(tokens[len(tokens)-1]) {
+				return nil, newParseError(*index, "Indexer without host")
+			}
+
+			(*index)++
+			key, err := parser.parseTokenBlock(nil, data, index, false)
+			if err != nil {
+				return nil, err
+			}
+
+			if *index >= len(*data) || (*data)[*index] != ']' {
+				return nil, newParseError(*index, "Indexer not terminated")
+			}
+
+			(*index)++
+			tokens[len(tokens)-1] = &Indexer{Host: tokens[len(tokens)-1], Index: key, conversions: parser.conversions}
+			concat = false
 		case ',', ']', '}', ')':
 			done = true
 		default:
-			if !concat {
+			if len(tokens) > 0 && !isOperator(tokens[len(tokens)-1]) {
+				operator, typename, err := parser.parseTypeOperator(data, index)
+				if err != nil {
+					return nil, err
+				}
+
+				if operator != nil {
+					operators = append(operators, &operatorIndex{index: len(tokens), operator: operator})
+					tokens = append(tokens, operator, typename)
+					concat = false
+					break
+				}
+
+				if readIdentifier(data, *index) == "when" {
+					done = true
+					break
+				}
+
+				if readIdentifier(data, *index) == "in" {
+					*index += 2
+					operator := &Operator{Type: OP_In, Class: OP_Binary}
+					operators = append(operators, &operatorIndex{index: len(tokens), operator: operator})
+					tokens = append(tokens, operator)
+					concat = true
+					break
+				}
+			}
+
+			if !concat || !isTokenStart(data, *index) {
 				done = true
 				break
 			}
 
-			token, err := parser.parseToken(data, index, startofstatement)
+			token, err := parser.parseToken(data, index, startofstatement && len(tokens) == 0)
 			if err != nil {
 				return nil, err
 			}
 
-			// TODO: check for return and control tokens
 			tokens = append(tokens, token)
+			concat = false
 		}
 
 		if *index == start && !done {
@@ -497,9 +916,13 @@ func (parser *Parser) parseTokenBlock(parent Token, data *string, index *int, st
 		}
 	}
 
+	if len(tokens) == 0 {
+		return nil, newParseError(*index, "Expression expected")
+	}
+
 	if len(tokens) > 1 {
 		sort.SliceStable(operators, func(i, k int) bool {
-			return operators[i].operator.Type < operators[k].operator.Type
+			return operatorPrecedence[operators[i].operator.Type] < operatorPrecedence[operators[k].operator.Type]
 		})
 
 		for _, opindex := range operators {
@@ -541,6 +964,164 @@ func (parser *Parser) parseTokenBlock(parent Token, data *string, index *int, st
 	return tokens[0], nil
 }
 
+// parseTypeOperator parses the type operators 'is' and 'as' followed by a type name
+//
+// **Returns**
+//   the operator and the type name or nil if no type operator starts at index
+func (parser *Parser) parseTypeOperator(data *string, index *int) (*Operator, Token, error) {
+	keyword := readIdentifier(data, *index)
+	var operator *Operator
+	switch keyword {
+	case "is":
+		operator = &Operator{Type: OP_Is, Class: OP_Binary}
+	case "as":
+		operator = &Operator{Type: OP_As, Class: OP_Binary}
+	default:
+		return nil, nil, nil
+	}
+
+	*index += len(keyword)
+	skipWhiteSpaces(data, index)
+	name := readIdentifier(data, *index)
+	if len(name) == 0 {
+		return nil, nil, newParseError(*index, "Type name expected")
+	}
+	if !parser.conversions.isTypeName(name) {
+		return nil, nil, newParseError(*index, "Unknown type '%s'", name)
+	}
+
+	*index += len(name)
+	return operator, &TypeName{Name: name, conversions: parser.conversions}, nil
+}
+
+// parseMatch parses a match expression with index pointing to the value to match
+//
+//   match (value) { pattern when guard => result, ... }
+func (parser *Parser) parseMatch(data *string, index *int) (Token, error) {
+	value, err := parser.parseSingleParameter(data, index)
+	if err != nil {
+		return nil, err
+	}
+
+	skipWhiteSpaces(data, index)
+	if *index >= len(*data) || (*data)[*index] != '{' {
+		return nil, newParseError(*index, "Match arms expected")
+	}
+	(*index)++
+
+	match := &Match{Value: value}
+	for {
+		skipWhiteSpaces(data, index)
+		if *index >= len(*data) {
+			return nil, newParseError(*index, "Match expression not terminated")
+		}
+
+		switch (*data)[*index] {
+		case '}':
+			(*index)++
+			if len(match.Arms) == 0 {
+				return nil, newParseError(*index-1, "Match arms expected")
+			}
+			return match, nil
+		case ',':
+			(*index)++
+		default:
+			arm, err := parser.parseMatchArm(data, index)
+			if err != nil {
+				return nil, err
+			}
+			match.Arms = append(match.Arms, arm)
+		}
+	}
+}
+
+// parseMatchArm parses an arm of a match expression like 'pattern when guard => result'
+//
+// Patterns are either '_' matching every value, a type name, a regular expression like
+// '~~ "^DEV-"' or an expression evaluating to a value or a range.
+func (parser *Parser) parseMatchArm(data *string, index *int) (*MatchArm, error) {
+	arm := &MatchArm{}
+
+	name := readIdentifier(data, *index)
+	end := *index + len(name)
+	skipWhiteSpaces(data, &end)
+	patternend := strings.HasPrefix((*data)[end:], "=>") || readIdentifier(data, end) == "when"
+	switch {
+	case name == "_" && patternend:
+		arm.Pattern = &discardPattern{}
+		*index = end
+	case name != "null" && parser.conversions.isTypeName(name) && patternend:
+		arm.Pattern = &typePattern{typename: &TypeName{Name: name, conversions: parser.conversions}}
+		*index = end
+	case strings.HasPrefix((*data)[*index:], "~~"):
+		*index += 2
+		expression, err := parser.parseTokenBlock(nil, data, index, false)
+		if err != nil {
+			return nil, err
+		}
+		arm.Pattern = &regexPattern{expression: expression}
+	default:
+		expression, err := parser.parseTokenBlock(nil, data, index, false)
+		if err != nil {
+			return nil, err
+		}
+		arm.Pattern = &valuePattern{expression: expression}
+	}
+
+	skipWhiteSpaces(data, index)
+	if readIdentifier(data, *index) == "when" {
+		*index += 4
+		guard, err := parser.parseTokenBlock(nil, data, index, false)
+		if err != nil {
+			return nil, err
+		}
+		arm.Guard = guard
+	}
+
+	skipWhiteSpaces(data, index)
+	if !strings.HasPrefix((*data)[*index:], "=>") {
+		return nil, newParseError(*index, "'=>' expected")
+	}
+	*index += 2
+
+	result, err := parser.parseTokenBlock(nil, data, index, false)
+	if err != nil {
+		return nil, err
+	}
+	arm.Result = result
+	return arm, nil
+}
+
+// parseRangeOperator parses the range operators .. and ..<
+//
+// Ranges without start or end are open, so a null value is inserted for the missing bound.
+func (parser *Parser) parseRangeOperator(tokens []Token, operators []*operatorIndex, data *string, index *int) ([]Token, []*operatorIndex) {
+	if len(tokens) == 0 || isOperator(tokens[len(tokens)-1]) {
+		tokens = append(tokens, &Value{})
+	}
+
+	operator := &Operator{Type: OP_Range, Class: OP_Binary}
+	*index += 2
+	if *index < len(*data) && (*data)[*index] == '<' {
+		operator.Type = OP_RangeExclusive
+		(*index)++
+	}
+
+	operators = append(operators, &operatorIndex{index: len(tokens), operator: operator})
+	tokens = append(tokens, operator)
+
+	switch peek(data, *index) {
+	case 0, ']', ')', ',', '}':
+		tokens = append(tokens, &Value{})
+	}
+	return tokens, operators
+}
+
+func isOperator(token Token) bool {
+	_, isop := token.(*Operator)
+	return isop
+}
+
 func adjustOperatorIndices(operators []*operatorIndex, index int, count int) {
 	for _, opindex := range operators {
 		if opindex.index > index {
@@ -552,10 +1133,11 @@ func removeAt(tokens []Token, index int) []Token {
 	return append(tokens[:index], tokens[index+1:]...)
 }
 
-func (parser *Parser) parseStatementBlock(parent Token, data *string, index *int, methodblock bool) (Token, error) {
+func (parser *Parser) parseStatementBlock(parent Token, data *string, index *int, methodblock bool) (*StatementBlock, error) {
 	skipWhiteSpaces(data, index)
 
 	var statements []Token
+	var positions []int
 
 	terminated := false
 	for *index < len(*data) {
@@ -565,6 +1147,13 @@ func (parser *Parser) parseStatementBlock(parent Token, data *string, index *int
 			break
 		}
 
+		if (*data)[*index] == ';' {
+			*index++
+			skipWhiteSpaces(data, index)
+			continue
+		}
+
+		position := *index
 		token, err := parser.parseTokenBlock(parent, data, index, true)
 		if err != nil {
 			return nil, err
@@ -572,6 +1161,7 @@ func (parser *Parser) parseStatementBlock(parent Token, data *string, index *int
 
 		if token != nil {
 			statements = append(statements, token)
+			positions = append(positions, position)
 		}
 
 		skipWhiteSpaces(data, index)
@@ -581,14 +1171,353 @@ func (parser *Parser) parseStatementBlock(parent Token, data *string, index *int
 		return nil, errors.New("Unterminated statement block")
 	}
 
-	if len(statements) <= 1 {
-		return &StatementBlock{
-			Body:     statements,
-			IsMethod: methodblock}, nil
+	return &StatementBlock{
+		Body:      statements,
+		IsMethod:  methodblock,
+		positions: positions}, nil
+}
+
+// parseNew parses a new expression with index pointing behind the new keyword
+//
+//   new Address { Street = "Main Street", Zip = 12345 }
+//   new Point(1, 2)
+func (parser *Parser) parseNew(data *string, index *int) (Token, error) {
+	skipWhiteSpaces(data, index)
+	name := readIdentifier(data, *index)
+	if len(name) == 0 {
+		return nil, newParseError(*index, "Type name expected")
 	}
+	if !parser.types.IsRegistered(name) {
+		return nil, newParseError(*index, "Type '%s' is not registered", name)
+	}
+	*index += len(name)
 
-	// TODO: fetch control tokens as soon as they are supported
-	return &StatementBlock{
-		Body:     statements,
-		IsMethod: methodblock}, nil
+	construction := &New{
+		TypeName:    name,
+		types:       parser.types,
+		conversions: parser.conversions}
+
+	if peek(data, *index) == '(' {
+		arguments, err := parser.parseParameters(data, index)
+		if err != nil {
+			return nil, err
+		}
+		construction.Arguments = arguments
+	}
+
+	if peek(data, *index) != '{' {
+		return construction, nil
+	}
+
+	skipWhiteSpaces(data, index)
+	(*index)++
+	for {
+		skipWhiteSpaces(data, index)
+		if *index >= len(*data) {
+			return nil, newParseError(*index, "Initializer not terminated")
+		}
+
+		switch (*data)[*index] {
+		case '}':
+			(*index)++
+			return construction, nil
+		case ',':
+			(*index)++
+		default:
+			field := readIdentifier(data, *index)
+			if len(field) == 0 {
+				return nil, newParseError(*index, "Member name expected")
+			}
+			*index += len(field)
+
+			if !parser.parseInitializer(data, index) {
+				return nil, newParseError(*index, "'=' expected")
+			}
+
+			value, err := parser.parseTokenBlock(nil, data, index, false)
+			if err != nil {
+				return nil, err
+			}
+			construction.Initializers = append(construction.Initializers, &FieldInitializer{Name: field, Value: value})
+		}
+	}
+}
+
+// parseDeclaration parses a variable declaration with index pointing behind the keyword
+//
+//   var name = value
+func (parser *Parser) parseDeclaration(keyword string, data *string, index *int) (Token, error) {
+	skipWhiteSpaces(data, index)
+	if *index < len(*data) && ((*data)[*index] == '[' || (*data)[*index] == '{') {
+		return parser.parseDestructuring(keyword, data, index)
+	}
+
+	name := readIdentifier(data, *index)
+	if len(name) == 0 {
+		return nil, newParseError(*index, "Variable name expected")
+	}
+	*index += len(name)
+
+	declaration := &Declaration{Name: name, Constant: keyword == "const"}
+	if !parser.parseInitializer(data, index) {
+		if declaration.Constant {
+			return nil, newParseError(*index, "Constant '%s' has to be initialized", name)
+		}
+		return declaration, nil
+	}
+
+	value, err := parser.parseTokenBlock(nil, data, index, false)
+	if err != nil {
+		return nil, err
+	}
+	declaration.Value = value
+	return declaration, nil
+}
+
+// parseDestructuring parses a destructuring declaration with index pointing to the pattern
+//
+//   var [first, second = 0] = list
+//   var { name, age = 0 } = person
+func (parser *Parser) parseDestructuring(keyword string, data *string, index *int) (Token, error) {
+	destructuring := &Destructuring{
+		Array:    (*data)[*index] == '[',
+		Constant: keyword == "const"}
+	terminator := byte('}')
+	if destructuring.Array {
+		terminator = ']'
+	}
+
+	(*index)++
+	for {
+		skipWhiteSpaces(data, index)
+		if *index >= len(*data) {
+			return nil, newParseError(*index, "Destructuring pattern not terminated")
+		}
+
+		if (*data)[*index] == terminator {
+			(*index)++
+			break
+		}
+		if (*data)[*index] == ',' && len(destructuring.Targets) > 0 {
+			(*index)++
+			continue
+		}
+
+		name := readIdentifier(data, *index)
+		if len(name) == 0 {
+			return nil, newParseError(*index, "Variable name expected")
+		}
+		*index += len(name)
+
+		target := &DestructuringTarget{Name: name}
+		if parser.parseInitializer(data, index) {
+			value, err := parser.parseTokenBlock(nil, data, index, false)
+			if err != nil {
+				return nil, err
+			}
+			target.Default = value
+		}
+		destructuring.Targets = append(destructuring.Targets, target)
+	}
+
+	if len(destructuring.Targets) == 0 {
+		return nil, newParseError(*index, "Variable name expected")
+	}
+
+	if !parser.parseInitializer(data, index) {
+		return nil, newParseError(*index, "Destructuring declaration has to be initialized")
+	}
+
+	value, err := parser.parseTokenBlock(nil, data, index, false)
+	if err != nil {
+		return nil, err
+	}
+	destructuring.Value = value
+	return destructuring, nil
+}
+
+// parseInitializer skips the '=' starting an initializer
+//
+// **Returns**
+//   true if an initializer follows, false otherwise
+func (parser *Parser) parseInitializer(data *string, index *int) bool {
+	skipWhiteSpaces(data, index)
+	if *index < len(*data) && (*data)[*index] == '=' && !strings.HasPrefix((*data)[*index:], "==") {
+		(*index)++
+		return true
+	}
+	return false
+}
+
+// parseFunction parses a function declaration with index pointing behind the function keyword
+//
+//   function gross(net, rate) { net * (1 + rate) }
+func (parser *Parser) parseFunction(data *string, index *int) (Token, error) {
+	skipWhiteSpaces(data, index)
+	name := readIdentifier(data, *index)
+	*index += len(name)
+
+	skipWhiteSpaces(data, index)
+	if *index >= len(*data) || (*data)[*index] != '(' {
+		return nil, newParseError(*index, "'(' expected")
+	}
+
+	(*index)++
+	declaration := &FunctionDeclaration{Name: name}
+	for {
+		skipWhiteSpaces(data, index)
+		if *index >= len(*data) {
+			return nil, newParseError(*index, "Parameter list not terminated")
+		}
+
+		if (*data)[*index] == ')' {
+			(*index)++
+			break
+		}
+		if (*data)[*index] == ',' && len(declaration.Parameters) > 0 {
+			(*index)++
+			continue
+		}
+
+		parameter := readIdentifier(data, *index)
+		if len(parameter) == 0 {
+			return nil, newParseError(*index, "Parameter name expected")
+		}
+		*index += len(parameter)
+		declaration.Parameters = append(declaration.Parameters, parameter)
+	}
+
+	body, err := parser.parseCodeBlock(data, index)
+	if err != nil {
+		return nil, err
+	}
+	declaration.Body = body
+	return declaration, nil
+}
+
+// parseImport parses an import statement with index pointing behind the import keyword
+//
+//   import "common/pricing.ncs" as pricing
+func (parser *Parser) parseImport(data *string, index *int) (Token, error) {
+	skipWhiteSpaces(data, index)
+	start := *index
+	(*index)++
+	literal, err := parseLiteral(data, index)
+	if err != nil {
+		return nil, err
+	}
+	modulepath := literal.(*Value).Value.(string)
+
+	namespace := moduleNamespace(modulepath)
+	next := *index
+	skipWhiteSpaces(data, &next)
+	if readIdentifier(data, next) == "as" {
+		*index = next + 2
+		skipWhiteSpaces(data, index)
+		namespace = readIdentifier(data, *index)
+		if len(namespace) == 0 {
+			return nil, newParseError(*index, "Namespace expected")
+		}
+		*index += len(namespace)
+	}
+	if len(namespace) == 0 {
+		return nil, newParseError(start, "Module '%s' has to be imported using 'as namespace'", modulepath)
+	}
+
+	module, err := parser.parseModule(modulepath)
+	if err != nil {
+		return nil, newParseError(start, "%v", err)
+	}
+
+	return &Import{
+		Path:      modulepath,
+		Namespace: namespace,
+		module:    module}, nil
+}
+
+// parseExport parses an exported declaration with index pointing behind the export keyword
+func (parser *Parser) parseExport(data *string, index *int) (Token, error) {
+	skipWhiteSpaces(data, index)
+	start := *index
+	keyword := readIdentifier(data, *index)
+	*index += len(keyword)
+
+	var declaration Token
+	var err error
+	switch keyword {
+	case "var", "let", "const":
+		declaration, err = parser.parseDeclaration(keyword, data, index)
+	case "function":
+		declaration, err = parser.parseFunction(data, index)
+	default:
+		return nil, newParseError(start, "Only declarations can be exported")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Export{Declaration: declaration}, nil
+}
+
+// parseCodeBlock parses a statement block enclosed in braces
+func (parser *Parser) parseCodeBlock(data *string, index *int) (*StatementBlock, error) {
+	skipWhiteSpaces(data, index)
+	if *index >= len(*data) || (*data)[*index] != '{' {
+		return nil, newParseError(*index, "'{' expected")
+	}
+
+	(*index)++
+	return parser.parseStatementBlock(nil, data, index, false)
+}
+
+// parseTry parses a try statement with index pointing behind the try keyword
+//
+//   try { ... } catch (e) { ... } finally { ... }
+func (parser *Parser) parseTry(data *string, index *int) (Token, error) {
+	start := *index
+	body, err := parser.parseCodeBlock(data, index)
+	if err != nil {
+		return nil, err
+	}
+
+	try := &Try{Body: body}
+	skipWhiteSpaces(data, index)
+	if readIdentifier(data, *index) == "catch" {
+		*index += 5
+		skipWhiteSpaces(data, index)
+		if *index < len(*data) && (*data)[*index] == '(' {
+			(*index)++
+			skipWhiteSpaces(data, index)
+			try.Variable = readIdentifier(data, *index)
+			if len(try.Variable) == 0 {
+				return nil, newParseError(*index, "Variable name expected")
+			}
+
+			*index += len(try.Variable)
+			skipWhiteSpaces(data, index)
+			if *index >= len(*data) || (*data)[*index] != ')' {
+				return nil, newParseError(*index, "')' expected")
+			}
+			(*index)++
+		}
+
+		try.Catch, err = parser.parseCodeBlock(data, index)
+		if err != nil {
+			return nil, err
+		}
+		skipWhiteSpaces(data, index)
+	}
+
+	if readIdentifier(data, *index) == "finally" {
+		*index += 7
+		try.Finally, err = parser.parseCodeBlock(data, index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if try.Catch == nil && try.Finally == nil {
+		return nil, newParseError(start, "Try without catch or finally")
+	}
+	return try, nil
 }
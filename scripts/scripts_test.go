@@ -1,7 +1,17 @@
 package scripts
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -121,7 +131,7 @@ func Test_ComplexBlock1(t *testing.T) {
 	vars.SetVariable("z", 104.097663)
 	vars.SetVariable("a", 38.999995)
 	result, err := script.Execute(vars)
-	require.Equal(t, 765.39162416926, result)
+	require.Equal(t, 765.3916764441784, result)
 }
 
 func Test_ComplexBlock2(t *testing.T) {
@@ -136,5 +146,1905 @@ func Test_ComplexBlock2(t *testing.T) {
 	vars.SetVariable("z", 104.097663)
 	vars.SetVariable("a", 38.999995)
 	result, err := script.Execute(vars)
-	require.Equal(t, 765.39162416926, result)
+	require.Equal(t, 765.3916764441784, result)
+}
+
+func Test_UnicodeVariable(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("größe_1+ñ")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetVariable("größe_1", 3)
+	vars.SetVariable("ñ", 4)
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), result)
+}
+
+func Test_UnicodeLiteral(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("\"Grüße, 世界\"")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "Grüße, 世界", result)
+}
+
+func Test_UnicodeCharacter(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("'ß'")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, Char('ß'), result)
+}
+
+func Test_Characters(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, expected := range map[string]interface{}{
+		"'a' is char":   true,
+		"'a' is int":    false,
+		"65 is char":    false,
+		"typeof('a')":   "char",
+		"typeof(65)":    "int",
+		"string('a')":   "a",
+		"$\"{'a'}\"":    "a",
+		"\"a\" + 'b'":   "ab",
+		"\"5\" + 'b'":   "5b",
+		"'b' + \"5\"":   "b5",
+		"'a' + 1":       int32(98),
+		"'a' == 97":     true,
+		"'a' < 'b'":     true,
+		"char('a' + 1)": Char('b'),
+		"int('a')":      int32(97),
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_UnicodeInterpolation(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("$\"Ärger mit {straße}\"")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetVariable("straße", "Müller")
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, "Ärger mit Müller", result)
+}
+
+func Test_EscapeSequences(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("\"\\u00e4\\U0001F600\\x41\\0\\a\\b\\f\\v\\t\\\\\"")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "ä😀A\x00\a\b\f\v\t\\", result)
+}
+
+func Test_EscapeCharacter(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("'\\u00df'")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, Char('ß'), result)
+}
+
+func Test_EscapeInterpolation(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("$\"\\x3E {name}\\u0021\"")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetVariable("name", "x")
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, "> x!", result)
+}
+
+func Test_UnknownEscapeSequence(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	_, err := parser.Parse("\"abc\\q\"")
+	require.Error(t, err)
+
+	parseerror, ok := err.(*ParseError)
+	require.True(t, ok)
+	require.Equal(t, 4, parseerror.Position)
+}
+
+func Test_InvalidUnicodeEscape(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	_, err := parser.Parse("\"\\u00g1\"")
+	require.Error(t, err)
+}
+
+func Test_VerbatimLiteral(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("@\"C:\\path\\file \"\"quoted\"\"\"")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "C:\\path\\file \"quoted\"", result)
+}
+
+func Test_VerbatimMultiLine(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("@\"^\\d+\n\\w*$\"")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "^\\d+\n\\w*$", result)
+}
+
+func Test_VerbatimInterpolation(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for _, code := range []string{"$@\"C:\\{dir}\\\"\"a\"\".txt\"", "@$\"C:\\{dir}\\\"\"a\"\".txt\""} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err)
+
+		vars := NewVariables(nil)
+		vars.SetVariable("dir", "temp")
+		result, err := script.Execute(vars)
+		require.NoError(t, err)
+		require.Equal(t, "C:\\temp\\\"a\".txt", result)
+	}
+}
+
+func Test_InterpolationFormat(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("amount", 1234.5)
+	vars.SetVariable("count", 255)
+	vars.SetVariable("ratio", 0.1234)
+	vars.SetVariable("big", int64(9007199254740993))
+	vars.SetVariable("date", time.Date(2020, 1, 31, 14, 5, 9, 0, time.UTC))
+
+	for code, expected := range map[string]string{
+		"$\"{amount:F2}\"":                 "1234.50",
+		"$\"{amount:N1}\"":                 "1,234.5",
+		"$\"{amount:E2}\"":                 "1.23E+003",
+		"$\"{amount:#,##0.000}\"":          "1,234.500",
+		"$\"{amount:0.#}\"":                "1234.5",
+		"$\"{count:X4}\"":                  "00FF",
+		"$\"{count:x}\"":                   "ff",
+		"$\"{count:D5}\"":                  "00255",
+		"$\"{ratio:P1}\"":                  "12.3 %",
+		"$\"[{count,6}]\"":                 "[   255]",
+		"$\"[{count,-6}]\"":                "[255   ]",
+		"$\"[{amount,10:F1}]\"":            "[    1234.5]",
+		"$\"{date:yyyy-MM-dd HH:mm:ss}\"":  "2020-01-31 14:05:09",
+		"$\"{date:dd.MM.yy}\"":             "31.01.20",
+		"$\"{date:ddd, d MMM yyyy h tt}\"": "Fri, 31 Jan 2020 2 PM",
+		"$\"{date:d}\"":                    "01/31/2020",
+		"$\"{NaN:E2}\"":                    "NaN",
+		"$\"{Infinity:E}\"":                "∞",
+		"$\"{-Infinity:e3}\"":              "-∞",
+		"$\"{2.5:F0}\"":                    "3",
+		"$\"{-2.5:F0}\"":                   "-3",
+		"$\"{0.125:F2}\"":                  "0.13",
+		"$\"{0.125:N2}\"":                  "0.13",
+		"$\"{0.00125:P1}\"":                "0.1 %",
+		"$\"{0.125:0.##}\"":                "0.13",
+		"$\"{big:#,##0}\"":                 "9,007,199,254,740,993",
+		"$\"{big:F1}\"":                    "9007199254740993.0",
+		"$\"{{literal}}\"":                 "{literal}",
+		"$\"{{{count}}}\"":                 "{255}",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_InterpolationInvalidFormat(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("$\"{amount:Q}\"")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetVariable("amount", 2.5)
+	_, err = script.Execute(vars)
+	require.Error(t, err)
+}
+
+func Test_DecimalLiteral(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("0.1d+0.2d")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "0.3", result.(Decimal).String())
+}
+
+func Test_DecimalArithmetic(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("price", NewDecimal(1999, 2))
+	vars.SetVariable("quantity", 3)
+
+	for code, expected := range map[string]string{
+		"price*quantity":          "59.97",
+		"price-0.99d":             "19.00",
+		"price+1":                 "20.99",
+		"10d/4":                   "2.5",
+		"1d/3d":                   "0.3333333333333333333333333333",
+		"2d/3d":                   "0.6666666666666666666666666667",
+		"-price":                  "-19.99",
+		"decimal(\"12.345\")*2":   "24.690",
+		"decimal(0.1)+decimal(2)": "2.1",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result.(Decimal).String(), code)
+	}
+}
+
+func Test_DecimalComparision(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, expected := range map[string]bool{
+		"1.50d==1.5d": true,
+		"0.1d<0.2d":   true,
+		"2d<=1.99d":   false,
+		"5d>4":        true,
+		"3d>=3.0":     true,
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_DecimalDivisionByZero(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("1d/0")
+	require.NoError(t, err)
+
+	_, err = script.Execute(nil)
+	require.Error(t, err)
+}
+
+func Test_DecimalExponentRange(t *testing.T) {
+	value, err := ParseDecimal("1.5e3")
+	require.NoError(t, err)
+	require.Equal(t, "1500", value.String())
+
+	_, err = ParseDecimal("1e999999999")
+	require.EqualError(t, err, "Exponent of decimal '1e999999999' is out of range")
+	_, err = ParseDecimal("1e-999999999")
+	require.EqualError(t, err, "Exponent of decimal '1e-999999999' is out of range")
+}
+
+func Test_DecimalFormat(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("$\"{total:N2}\"")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetVariable("total", NewDecimal(123456785, 3))
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, "123,456.79", result)
+}
+
+func Test_BigIntegerLiteral(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("123456789012345678901234567890n+1")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "123456789012345678901234567891", result.(*big.Int).String())
+}
+
+func Test_BigIntegerLiteralWithoutSuffix(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("99999999999999999999")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "99999999999999999999", result.(*big.Int).String())
+}
+
+func Test_BigIntegerHexLiteral(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("0xFFFFFFFFFFFFFFFFFFn")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "4722366482869645213695", result.(*big.Int).String())
+}
+
+func Test_IntegerOverflowWraps(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("x*2")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetVariable("x", 1500000000)
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, int64(3000000000), result)
+}
+
+func Test_IntegerOverflowPromotes(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetOptions(Options{PromoteBigIntegers: true})
+	vars.SetVariable("x", big.NewInt(math.MaxInt64))
+	vars.SetVariable("y", int64(math.MaxInt64))
+
+	script, err := parser.Parse("x*2")
+	require.NoError(t, err)
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, "18446744073709551614", result.(*big.Int).String())
+
+	script, err = parser.Parse("-(x+1)")
+	require.NoError(t, err)
+	result, err = script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, "-9223372036854775808", result.(*big.Int).String())
+}
+
+func Test_BigIntegerComparision(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, expected := range map[string]bool{
+		"100000000000000000000n>5": true,
+		"5n==5":                    true,
+		"5n<5.5":                   true,
+		"5n<=4.99d":                false,
+		"bigint(\"12\")==12n":      true,
+		"decimal(12n)==12.0d":      true,
+		"double(12n)==12.0":        true,
+		"bigint(12.9)==12n":        true,
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_UnsupportedOperandsError(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, message := range map[string]string{
+		"true < 1":   "Comparision not supported for 'true' and '1'",
+		"2 <= false": "Comparision not supported for '2' and 'false'",
+		"true + 1":   "Operator '+' not supported for 'true' and '1'",
+		"1 % false":  "Operator '%' not supported for '1' and 'false'",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(nil)
+		require.EqualError(t, err, message, code)
+	}
+}
+
+func Test_NumberLiterals(t *testing.T) {
+	for token, expected := range map[string]interface{}{
+		"12":                   int32(12),
+		"3000000000":           uint32(3000000000),
+		"5000000000":           int64(5000000000),
+		"10000000000000000000": uint64(10000000000000000000),
+		"12l":                  int64(12),
+		"12ul":                 uint64(12),
+		"12s":                  int16(12),
+		"12us":                 uint16(12),
+		"12b":                  int8(12),
+		"12sb":                 uint8(12),
+		"12u":                  uint32(12),
+		"0x1F":                 int32(31),
+		"0x1B":                 int32(27),
+		"0xFFFFFFFF":           uint32(0xFFFFFFFF),
+		"0x1Fl":                int64(31),
+		"0x1Fu":                uint32(31),
+		"0o17":                 int32(15),
+		"0o17s":                int16(15),
+		"0b101":                int32(5),
+		"0b101sb":              uint8(5),
+		"1.5":                  1.5,
+		"1.5f":                 float32(1.5),
+		"1e-6":                 1e-6,
+		"6.02E23":              6.02e23,
+		"2.5e+3":               2500.0,
+		"1e3f":                 float32(1000),
+		".5":                   0.5,
+		"1_000_000":            int32(1000000),
+		"0xFF_FF":              int32(65535),
+		"0b1010_1":             int32(21),
+		"1_000.5":              1000.5,
+	} {
+		value, err := parseNumber(token)
+		require.NoError(t, err, token)
+		require.Equal(t, expected, value, token)
+	}
+}
+
+func Test_InvalidDigitSeparators(t *testing.T) {
+	for _, token := range []string{"1_", "1__", "1_.5", "1._5", "0x_1"} {
+		_, err := parseNumber(token)
+		require.Error(t, err, token)
+	}
+}
+
+func Test_ScientificNotation(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, expected := range map[string]interface{}{
+		"1e-6*2":         2e-6,
+		"6.02E23/2":      3.01e23,
+		".5+.25":         0.75,
+		"1-.5":           0.5,
+		"1_000_000+1":    int32(1000001),
+		"1.5e3d==1500d":  true,
+		"0x1e-5":         int32(25),
+		"-Infinity<0":    true,
+		"Infinity>1e308": true,
+		"string(NaN)":    "NaN",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+// Test_NumericPromotion documents the result types of binary arithmetic which follow
+// the binary numeric promotion of C#
+func Test_NumericPromotion(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, expected := range map[string]interface{}{
+		"5b+1b":          int32(6),
+		"5sb+1sb":        int32(6),
+		"5s+1us":         int32(6),
+		"5+1":            int32(6),
+		"5u+1u":          uint32(6),
+		"5u+1":           int64(6),
+		"5u+1b":          int64(6),
+		"5u+1sb":         uint32(6),
+		"5l+1":           int64(6),
+		"5l+1u":          int64(6),
+		"5ul+1":          uint64(6),
+		"5ul+1u":         uint64(6),
+		"5ul+1l":         uint64(6),
+		"5+1.5f":         float32(6.5),
+		"5l+1.5f":        float32(6.5),
+		"5+1.5":          6.5,
+		"1.5f+1.5":       3.0,
+		"5+1.5d":         NewDecimal(65, 1),
+		"1.5+1.5d":       NewDecimal(30, 1),
+		"5n+1":           big.NewInt(6),
+		"5n+1ul":         big.NewInt(6),
+		"5n+1.5":         6.5,
+		"5n+1.5f":        6.5,
+		"7/2":            int32(3),
+		"7%3":            int32(1),
+		"-7%3":           int32(-1),
+		"7.5%2":          1.5,
+		"7.5d%2":         NewDecimal(15, 1),
+		"-5u":            int64(-5),
+		"-5b":            int32(-5),
+		"2147483647+1":   int32(-2147483648),
+		"4294967295u+1u": uint32(0),
+		"\"2\"*3":        int64(6),
+		"\"2.5\"*2":      5.0,
+		"\"a\"+1":        "a1",
+		"1+\"2\"":        int64(3),
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		if decimal, ok := expected.(Decimal); ok {
+			require.Equal(t, decimal.String(), result.(Decimal).String(), code)
+			continue
+		}
+		if integer, ok := expected.(*big.Int); ok {
+			require.Equal(t, integer.String(), result.(*big.Int).String(), code)
+			continue
+		}
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_NumericPromotionNegativeULong(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("5ul+x")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetVariable("x", -1)
+	_, err = script.Execute(vars)
+	require.Error(t, err)
+}
+
+func Test_ULongAboveMaxInt64(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("x+1>x")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetVariable("x", uint64(math.MaxInt64)+10)
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, true, result)
+}
+
+func Test_IntegerDivisionByZero(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("1/0")
+	require.NoError(t, err)
+
+	_, err = script.Execute(nil)
+	require.Error(t, err)
+}
+
+func Test_CheckedArithmetic(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetOptions(Options{CheckedArithmetic: true})
+	vars.SetVariable("max", int64(math.MaxInt64))
+	vars.SetVariable("min", int64(math.MinInt64))
+
+	for _, code := range []string{
+		"max+1",
+		"min-1",
+		"max*2",
+		"-min",
+		"min/-1",
+		"2147483647+1",
+		"0u-1u",
+		"1<<31",
+		"max<<1",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(vars)
+		require.Error(t, err, code)
+
+		_, ok := err.(*OverflowError)
+		require.True(t, ok, code)
+	}
+}
+
+func Test_CheckedArithmeticWithoutOverflow(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetOptions(Options{CheckedArithmetic: true})
+
+	script, err := parser.Parse("2147483646+1")
+	require.NoError(t, err)
+
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, int32(2147483647), result)
+}
+
+func Test_CheckedExpression(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("checked(2147483647+1)")
+	require.NoError(t, err)
+
+	_, err = script.Execute(nil)
+	require.EqualError(t, err, "Arithmetic operation '+' on '2147483647' and '1' resulted in an overflow")
+	_, ok := err.(*OverflowError)
+	require.True(t, ok)
+}
+
+func Test_UncheckedExpression(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("unchecked(2147483647+1)")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetOptions(Options{CheckedArithmetic: true})
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, int32(math.MinInt32), result)
+}
+
+func Test_CheckedPromotesBigIntegers(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("checked(9223372036854775807l+1)")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetOptions(Options{PromoteBigIntegers: true})
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, "9223372036854775808", result.(*big.Int).String())
+}
+
+func Test_Shift(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, expected := range map[string]interface{}{
+		"1<<4":        int32(16),
+		"1<<33":       int32(2),
+		"1l<<33":      int64(8589934592),
+		"-16>>2":      int32(-4),
+		"16u>>2":      uint32(4),
+		"1b<<3":       int32(8),
+		"1<<31":       int32(math.MinInt32),
+		"1n<<100>>99": big.NewInt(2),
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		if integer, ok := expected.(*big.Int); ok {
+			require.Equal(t, integer.String(), result.(*big.Int).String(), code)
+			continue
+		}
+		require.Equal(t, expected, result, code)
+	}
+}
+
+type equalityHost struct {
+	Name string
+	Age  int
+}
+
+func Test_TypedEquality(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	host := &equalityHost{Name: "Gangolf", Age: 42}
+	vars := NewVariables(nil)
+	vars.SetVariable("host", host)
+	vars.SetVariable("same", host)
+	vars.SetVariable("copy", &equalityHost{Name: "Gangolf", Age: 42})
+	vars.SetVariable("value", equalityHost{Name: "Gangolf", Age: 42})
+	vars.SetVariable("other", equalityHost{Name: "Gangolf", Age: 42})
+	vars.SetVariable("list", []interface{}{1, "a", 2.5})
+	vars.SetVariable("equallist", []interface{}{int64(1), "a", 2.5})
+	vars.SetVariable("map", map[string]interface{}{"a": 1, "b": []int{1, 2}})
+	vars.SetVariable("equalmap", map[string]interface{}{"a": 1.0, "b": []int32{1, 2}})
+	vars.SetVariable("nothing", nil)
+
+	for code, expected := range map[string]bool{
+		"1==\"1\"":                   false,
+		"\"1\"==\"1\"":               true,
+		"1.0==1":                     true,
+		"1b==1ul":                    true,
+		"1.5d==1.5":                  true,
+		"-1==18446744073709551615ul": false,
+		"NaN==NaN":                   false,
+		"true==true":                 true,
+		"true==1":                    false,
+		"host==same":                 true,
+		"host==copy":                 false,
+		"value==other":               true,
+		"list==equallist":            true,
+		"map==equalmap":              true,
+		"nothing==null":              true,
+		"host==null":                 false,
+		"list!=equallist":            false,
+		"1!=\"1\"":                   true,
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_LooseEquality(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("1==\"1\"")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetOptions(Options{LooseEquality: true})
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, true, result)
+}
+
+type versionHost struct {
+	Major int
+	Minor int
+}
+
+func (version *versionHost) CompareTo(other interface{}) (int, error) {
+	otherversion, ok := other.(*versionHost)
+	if !ok {
+		return 0, errors.New("version expected")
+	}
+
+	if version.Major != otherversion.Major {
+		return version.Major - otherversion.Major, nil
+	}
+	return version.Minor - otherversion.Minor, nil
+}
+
+func (version *versionHost) Equals(other interface{}) (bool, error) {
+	comparision, err := version.CompareTo(other)
+	return comparision == 0, err
+}
+
+func Test_ComparableHost(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("old", &versionHost{Major: 1, Minor: 9})
+	vars.SetVariable("new", &versionHost{Major: 2, Minor: 0})
+	vars.SetVariable("same", &versionHost{Major: 2, Minor: 0})
+
+	for code, expected := range map[string]bool{
+		"old<new":   true,
+		"old>=new":  false,
+		"new>old":   true,
+		"new<=same": true,
+		"new==same": true,
+		"old!=new":  true,
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_ComparableHostError(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("version<1")
+	require.NoError(t, err)
+
+	vars := NewVariables(nil)
+	vars.SetVariable("version", &versionHost{Major: 1})
+	_, err = script.Execute(vars)
+	require.Error(t, err)
+}
+
+func Test_CompareTimes(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	created := time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)
+	vars := NewVariables(nil)
+	vars.SetVariable("created", created)
+	vars.SetVariable("shipped", created.Add(48*time.Hour))
+	vars.SetVariable("local", created.In(time.FixedZone("CET", 3600)))
+	vars.SetVariable("short", 5*time.Minute)
+	vars.SetVariable("long", 2*time.Hour)
+
+	for code, expected := range map[string]bool{
+		"created<shipped": true,
+		"created>shipped": false,
+		"created==local":  true,
+		"short<long":      true,
+		"long>=short":     true,
+		"short==long":     false,
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+type vectorHost struct {
+	X float64
+	Y float64
+}
+
+func (vector vectorHost) ScriptAdd(other interface{}) (interface{}, error) {
+	othervector, ok := other.(vectorHost)
+	if !ok {
+		return nil, errors.New("vector expected")
+	}
+	return vectorHost{X: vector.X + othervector.X, Y: vector.Y + othervector.Y}, nil
+}
+
+func (vector vectorHost) ScriptSub(other interface{}) (interface{}, error) {
+	othervector, ok := other.(vectorHost)
+	if !ok {
+		return nil, errors.New("vector expected")
+	}
+	return vectorHost{X: vector.X - othervector.X, Y: vector.Y - othervector.Y}, nil
+}
+
+func (vector vectorHost) ScriptMul(other interface{}) (interface{}, error) {
+	factor, err := castValue(other, CAST_DOUBLE)
+	if err != nil {
+		return nil, err
+	}
+	return vectorHost{X: vector.X * factor.(float64), Y: vector.Y * factor.(float64)}, nil
+}
+
+func (vector vectorHost) ScriptMulRight(other interface{}) (interface{}, error) {
+	return vector.ScriptMul(other)
+}
+
+func (vector vectorHost) ScriptDiv(other interface{}) (interface{}, error) {
+	divisor, err := castValue(other, CAST_DOUBLE)
+	if err != nil {
+		return nil, err
+	}
+	return vectorHost{X: vector.X / divisor.(float64), Y: vector.Y / divisor.(float64)}, nil
+}
+
+func (vector vectorHost) ScriptNeg() (interface{}, error) {
+	return vectorHost{X: -vector.X, Y: -vector.Y}, nil
+}
+
+func Test_OperatorOverloading(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("a", vectorHost{X: 1, Y: 2})
+	vars.SetVariable("b", vectorHost{X: 3, Y: 5})
+
+	for code, expected := range map[string]vectorHost{
+		"a+b":       {X: 4, Y: 7},
+		"b-a":       {X: 2, Y: 3},
+		"a*2":       {X: 2, Y: 4},
+		"2*a":       {X: 2, Y: 4},
+		"b/2":       {X: 1.5, Y: 2.5},
+		"-a":        {X: -1, Y: -2},
+		"(a+b)*2-a": {X: 7, Y: 12},
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_OperatorOverloadingError(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("a", vectorHost{X: 1, Y: 2})
+
+	for _, code := range []string{"a+1", "1+a", "2/a", "a%2"} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(vars)
+		require.Error(t, err, code)
+	}
+}
+
+// textHost host value whose + operator is not commutative
+type textHost struct {
+	Text string
+}
+
+func (text textHost) ScriptAdd(other interface{}) (interface{}, error) {
+	return textHost{Text: fmt.Sprintf("%s%v", text.Text, other)}, nil
+}
+
+func (text textHost) ScriptAddRight(other interface{}) (interface{}, error) {
+	return textHost{Text: fmt.Sprintf("%v%s", other, text.Text)}, nil
+}
+
+func Test_OperatorOverloadingKeepsOperandOrder(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("a", textHost{Text: "a"})
+	vars.SetVariable("b", textHost{Text: "b"})
+	vars.SetVariable("v", vectorHost{X: 1, Y: 2})
+
+	for code, expected := range map[string]interface{}{
+		"a+b":           textHost{Text: "a{b}"},
+		"b+a":           textHost{Text: "b{a}"},
+		"1+a":           textHost{Text: "1a"},
+		"a+1":           textHost{Text: "a1"},
+		"\"x\"+a":       "x{a}",
+		"a+\"x\"":       "{a}x",
+		"\"Total: \"+v": "Total: {1 2}",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_DurationLiterals(t *testing.T) {
+	for token, expected := range map[string]interface{}{
+		"5m":    5 * time.Minute,
+		"2h30m": 2*time.Hour + 30*time.Minute,
+		"1.5h":  90 * time.Minute,
+		"1d12h": 36 * time.Hour,
+		"1m30s": 90 * time.Second,
+		"250ms": 250 * time.Millisecond,
+		"7d":    NewDecimal(7, 0),
+		"30s":   int16(30),
+	} {
+		value, err := parseNumber(token)
+		require.NoError(t, err, token)
+		require.Equal(t, expected, value, token)
+	}
+}
+
+type orderHost struct {
+	Created time.Time
+}
+
+func Test_DateTimeArithmetic(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("order", &orderHost{Created: time.Now().Add(-10 * 24 * time.Hour)})
+	vars.SetVariable("due", time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	for code, expected := range map[string]interface{}{
+		"#2020-01-31#":                         time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC),
+		"#2020-01-31 12:30:00#":                time.Date(2020, 1, 31, 12, 30, 0, 0, time.UTC),
+		"#2020-01-31#+1d0h":                    time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+		"due-#2020-01-31#":                     24 * time.Hour,
+		"due-30m":                              time.Date(2020, 1, 31, 23, 30, 0, 0, time.UTC),
+		"2h+30m":                               150 * time.Minute,
+		"2h*3":                                 6 * time.Hour,
+		"3*2h":                                 6 * time.Hour,
+		"2h/4":                                 30 * time.Minute,
+		"3h/2h":                                1.5,
+		"-5m":                                  -5 * time.Minute,
+		"now()-order.Created>168h":             true,
+		"now()-order.Created>timespan(\"7d\")": true,
+		"datetime(\"2020-01-31\")<due":         true,
+		"timespan(\"1.02:30:00\")":             26*time.Hour + 30*time.Minute,
+		"timespan(1500)":                       1500 * time.Millisecond,
+		"\"due: \"+5m":                         "due: 5m0s",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_DateTimeArithmeticError(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for _, code := range []string{"#2020-01-31#+#2020-01-31#", "#2020-01-31#*2", "2h+1", "datetime(\"tomorrow\")"} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(nil)
+		require.Error(t, err, code)
+	}
+
+	_, err := parser.Parse("#2020-13-01#")
+	require.Error(t, err)
+}
+
+func Test_HostFunction(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("max", func(lhs float64, rhs float64) float64 {
+		return math.Max(lhs, rhs)
+	})
+	vars.SetVariable("fail", func() error {
+		return errors.New("failed")
+	})
+
+	script, err := parser.Parse("max(3, 7.5)")
+	require.NoError(t, err)
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, 7.5, result)
+
+	script, err = parser.Parse("fail()")
+	require.NoError(t, err)
+	_, err = script.Execute(vars)
+	require.EqualError(t, err, "failed")
+}
+
+func Test_Casts(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("list", []string{"a", "b"})
+
+	for code, expected := range map[string]interface{}{
+		"sbyte(-12)":               int8(-12),
+		"byte(255)":                uint8(255),
+		"short(\"-300\")":          int16(-300),
+		"ushort(65535)":            uint16(65535),
+		"int(12.9)":                int32(12),
+		"int(-12.9)":               int32(-12),
+		"uint(true)":               uint32(1),
+		"long(5000000000)":         int64(5000000000),
+		"ulong(12.5d)":             uint64(12),
+		"long(12n)":                int64(12),
+		"char(\"A\")":              Char('A'),
+		"char(65)":                 Char('A'),
+		"float(1.5)":               float32(1.5),
+		"double(\"2.5\")":          2.5,
+		"double(0.0000001d)":       0.0000001,
+		"bool(\"false\")":          false,
+		"bool(0.5)":                true,
+		"decimal(\"1.25\")":        NewDecimal(125, 2),
+		"datetime(\"2020-01-31\")": time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC),
+		"timespan(\"00:01:30\")":   90 * time.Second,
+		"array(list)":              []interface{}{"a", "b"},
+		"array(\"ab\")":            []interface{}{Char('a'), Char('b')},
+		"array(5)":                 []interface{}{int32(5)},
+		"array(null)":              []interface{}{},
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_ArrayCastCopiesHostSlice(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	items := []interface{}{"a", "b"}
+	vars := NewVariables(nil)
+	vars.SetVariable("items", items)
+
+	script, err := parser.Parse("array(items)")
+	require.NoError(t, err)
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+
+	result.([]interface{})[0] = "changed"
+	require.Equal(t, []interface{}{"a", "b"}, items)
+}
+
+func Test_CastErrors(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, message := range map[string]string{
+		"byte(256)":            "'256' is out of range for 'byte'",
+		"sbyte(-129)":          "'-129' is out of range for 'sbyte'",
+		"int(5000000000)":      "'5000000000' is out of range for 'int'",
+		"uint(-1)":             "'-1' is out of range for 'uint'",
+		"long(NaN)":            "'NaN' is out of range for 'long'",
+		"int(\"1.5\")":         "Unable to convert '1.5' to 'int'",
+		"char(\"ab\")":         "Unable to convert 'ab' to 'char' since it doesn't consist of exactly one character",
+		"bool(\"maybe\")":      "Unable to convert 'maybe' to 'bool'",
+		"double(#2020-01-31#)": "Unsupported cast from '2020-01-31 00:00:00 +0000 UTC' to 'double'",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(nil)
+		require.EqualError(t, err, message, code)
+	}
+}
+
+type moneyHost struct {
+	Cents int64
+}
+
+func convertMoney(value interface{}) (interface{}, error) {
+	amount, err := castValue(value, CAST_DECIMAL)
+	if err != nil {
+		return nil, err
+	}
+	cents, err := castValue(amount.(Decimal).Mul(NewDecimal(100, 0)), CAST_LONG)
+	if err != nil {
+		return nil, err
+	}
+	return moneyHost{Cents: cents.(int64)}, nil
+}
+
+func Test_RegisteredConversion(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	require.NoError(t, parser.Conversions().Register("money", reflect.TypeOf(moneyHost{}), convertMoney))
+	vars := NewVariables(nil)
+	vars.SetVariable("cents", func(money moneyHost) int64 {
+		return money.Cents
+	})
+
+	for code, expected := range map[string]interface{}{
+		"money(\"12.50\")": moneyHost{Cents: 1250},
+		"money(3)":         moneyHost{Cents: 300},
+		"cents(\"0.99\")":  int64(99),
+		"cents(money(2))":  int64(200),
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_RegisterBuiltinConversion(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	require.Error(t, parser.Conversions().Register("int", reflect.TypeOf(0), convertMoney))
+}
+
+func Test_HostFunctionArgumentConversion(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("repeat", func(text string, count int) string {
+		return strings.Repeat(text, count)
+	})
+
+	script, err := parser.Parse("repeat(\"ab\", \"3\")")
+	require.NoError(t, err)
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, "ababab", result)
+
+	script, err = parser.Parse("repeat(\"ab\", 1.5e20)")
+	require.NoError(t, err)
+	_, err = script.Execute(vars)
+	require.Error(t, err)
+}
+
+func Test_TypeOperators(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	require.NoError(t, parser.Conversions().Register("money", reflect.TypeOf(moneyHost{}), convertMoney))
+	vars := NewVariables(nil)
+	vars.SetVariable("payload", map[string]interface{}{"name": "device"})
+	vars.SetVariable("list", []interface{}{1.0, "a"})
+	vars.SetVariable("price", moneyHost{Cents: 100})
+	vars.SetVariable("missing", nil)
+
+	for code, expected := range map[string]interface{}{
+		"12 is int":            true,
+		"12 is long":           false,
+		"12l is long":          true,
+		"1.5 is double":        true,
+		"\"a\" is string":      true,
+		"1+2 is int":           true,
+		"(1.5) is int":         false,
+		"list is array":        true,
+		"payload is object":    true,
+		"missing is null":      true,
+		"missing is object":    false,
+		"price is money":       true,
+		"5m is timespan":       true,
+		"12 is int == true":    true,
+		"\"a\" as string":      "a",
+		"12 as string":         nil,
+		"price as money":       moneyHost{Cents: 100},
+		"typeof(12)":           "int",
+		"typeof(1.5f)":         "float",
+		"typeof(\"a\")":        "string",
+		"typeof(list)":         "array",
+		"typeof(payload)":      "object",
+		"typeof(missing)":      "null",
+		"typeof(price)":        "money",
+		"typeof(#2020-01-31#)": "datetime",
+		"typeof(12n)":          "bigint",
+		"typeof(12 as string)": "null",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_TypeofTypeRegisteredWithSeveralNames(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for _, name := range []string{"money", "cash", "funds"} {
+		require.NoError(t, parser.Conversions().Register(name, reflect.TypeOf(moneyHost{}), convertMoney))
+	}
+	vars := NewVariables(nil)
+	vars.SetVariable("price", moneyHost{Cents: 100})
+
+	script, err := parser.Parse("typeof(price)")
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		result, err := script.Execute(vars)
+		require.NoError(t, err)
+		require.Equal(t, "funds", result)
+	}
+
+	require.NoError(t, parser.Conversions().Register("funds", nil, convertMoney))
+	for i := 0; i < 20; i++ {
+		result, err := script.Execute(vars)
+		require.NoError(t, err)
+		require.Equal(t, "cash", result)
+	}
+}
+
+func Test_TypeOperatorUnknownType(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	_, err := parser.Parse("12 is number")
+	require.EqualError(t, err, "Unknown type 'number' at index 6")
+
+	_, err = parser.Parse("12 is")
+	require.Error(t, err)
+}
+
+func Test_Ranges(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("id", "DEV-0042-EU")
+	vars.SetVariable("list", []int{1, 2, 3, 4})
+	vars.SetVariable("names", map[string]interface{}{"a": 1})
+	vars.SetVariable("codes", map[int]string{1: "one"})
+
+	for code, expected := range map[string]interface{}{
+		"id[4..7]":       "0042",
+		"id[4..<8]":      "0042",
+		"id[..2]":        "DEV",
+		"id[^2..]":       "EU",
+		"id[..<^3]":      "DEV-0042",
+		"id[0]":          Char('D'),
+		"id[^1]":         Char('U'),
+		"list[1..2]":     []int{2, 3},
+		"list[..^1]":     []int{1, 2, 3, 4},
+		"list[..<^1]":    []int{1, 2, 3},
+		"list[^1]":       4,
+		"names[\"a\"]":   1,
+		"codes[1]":       "one",
+		"5 in 1..10":     true,
+		"10 in 1..<10":   false,
+		"1.5 in 1..2":    true,
+		"0 in 1..":       false,
+		"3 in list":      true,
+		"5 in list":      false,
+		"\"004\" in id":  true,
+		"\"a\" in names": true,
+		"array(1..3)":    []interface{}{int32(1), int32(2), int32(3)},
+		"array(1..<1)":   []interface{}{},
+		"typeof(1..2)":   "range",
+		"1..2 is range":  true,
+		"string(2..<5)":  "2..<5",
+		"id[1+1..2*2]":   "V-0",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_RangeErrors(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("id", "DEV")
+
+	vars.SetVariable("codes", map[int]string{1: "one"})
+
+	for _, code := range []string{"id[2..5]", "id[3]", "id[^4]", "id[\"a\"]", "array(1..)", "5[0]", "array(1..1000000000)", "codes[\"a\"]", "codes[2]"} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(vars)
+		require.Error(t, err, code)
+	}
+}
+
+func Test_Match(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse(`match (value) {
+		0 => "zero",
+		null => "none",
+		1..9 => "digit",
+		"high" => "text",
+		~~ "^DEV-" => "device",
+		string when value == "" => "empty",
+		string => "string",
+		double => "double",
+		int when value < 0 => "negative",
+		_ => "other"
+	}`)
+	require.NoError(t, err)
+
+	for value, expected := range map[interface{}]string{
+		int32(0):  "zero",
+		int32(5):  "digit",
+		"high":    "text",
+		"DEV-42":  "device",
+		"":        "empty",
+		"abc":     "string",
+		12.5:      "double",
+		int32(-3): "negative",
+		int32(42): "other",
+	} {
+		vars := NewVariables(nil)
+		vars.SetVariable("value", value)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, value)
+		require.Equal(t, expected, result, value)
+	}
+
+	vars := NewVariables(nil)
+	vars.SetVariable("value", nil)
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, "none", result)
+}
+
+func Test_MatchWithoutMatchingArm(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("match (5) { 1 => \"one\", string => \"string\" }")
+	require.NoError(t, err)
+
+	_, err = script.Execute(nil)
+	require.EqualError(t, err, "No pattern matches '5'")
+}
+
+func Test_MatchInExpression(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse("match (3) { 1..5 => 10, _ => 0 } * 2")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, int32(20), result)
+}
+
+func Test_RegexOperators(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, expected := range map[string]bool{
+		"\"DEV-0042\" ~~ \"^DEV-[0-9]+$\"": true,
+		"\"DEV-0042\" !~ \"^DEV-\"":        false,
+		"42 ~~ \"^4\"":                     true,
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_TryCatch(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("fail", func() error {
+		return errors.New("host failed")
+	})
+	vars.SetVariable("overflow", func() (int32, error) {
+		return 0, &OverflowError{Operator: OP_Add, LHS: int32(1), RHS: int32(2)}
+	})
+
+	for code, expected := range map[string]interface{}{
+		"try { throw \"failed\" } catch (e) { e.Message + \" \" + e.Kind + \" \" + e.Position }": "failed Error 6",
+		"try { fail() } catch (e) { e.Message }":                                                 "host failed",
+		"try { overflow() } catch (e) { e.Kind }":                                                "OverflowError",
+		"try { 1 + missing } catch (e) { e.Message }":                                            "'missing' not found",
+		"try { 5 } catch (e) { 0 }":                                                              int32(5),
+		"try { throw 42 } catch (e) { e.Value }":                                                 int32(42),
+		"try { \"abc\"[5] } catch { \"fallback\" }":                                              "fallback",
+		"try { try { throw \"inner\" } finally { 1 } } catch (e) { e.Message }":                  "inner",
+		"try { 1\n throw \"second\" } catch (e) { e.Position }":                                  9,
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_TryFinally(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	calls := 0
+	vars := NewVariables(nil)
+	vars.SetVariable("cleanup", func() {
+		calls++
+	})
+
+	script, err := parser.Parse("try { throw \"failed\" } finally { cleanup() }")
+	require.NoError(t, err)
+
+	_, err = script.Execute(vars)
+	require.EqualError(t, err, "failed")
+	require.Equal(t, 1, calls)
+
+	script, err = parser.Parse("try { 1 } catch { 2 } finally { cleanup() }; 3")
+	require.NoError(t, err)
+
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), result)
+	require.Equal(t, 2, calls)
+}
+
+func Test_RethrowGoError(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("overflow", func() (int32, error) {
+		return 0, &OverflowError{Operator: OP_Add, LHS: int32(1), RHS: int32(2)}
+	})
+
+	script, err := parser.Parse("try { overflow() } catch (e) { throw e }")
+	require.NoError(t, err)
+
+	_, err = script.Execute(vars)
+	var overflow *OverflowError
+	require.True(t, errors.As(err, &overflow))
+
+	_, err = parser.Parse("try { 1 }")
+	require.Error(t, err)
+}
+
+func Test_Declarations(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, expected := range map[string]interface{}{
+		"var x = 5; x * 2":             int32(10),
+		"let x = 5\nx = x + 1\nx":      int32(6),
+		"const rate = 0.5d; 10 * rate": NewDecimal(50, 1),
+		"var x; x":                     nil,
+		"var x = 1; try { var x = 2; x = 3 } catch { 0 }; x": int32(1),
+		"var x = 1; try { x = 2 } catch { 0 }; x":            int32(2),
+		"var x = 1 < 2; x": true,
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_DeclarationShadowsHostVariable(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("limit", int32(5))
+
+	script, err := parser.Parse("var limit = 10; limit")
+	require.NoError(t, err)
+
+	result, err := script.Execute(vars)
+	require.NoError(t, err)
+	require.Equal(t, int32(10), result)
+
+	value, err := vars.GetVariable("limit")
+	require.NoError(t, err)
+	require.Equal(t, int32(5), value)
+
+	script, err = parser.Parse("limit = 7")
+	require.NoError(t, err)
+
+	_, err = script.Execute(vars)
+	require.NoError(t, err)
+	value, err = vars.GetVariable("limit")
+	require.NoError(t, err)
+	require.Equal(t, int32(7), value)
+}
+
+func Test_DeclarationErrors(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	for code, message := range map[string]string{
+		"const x = 1; x = 2":   "Constant 'x' can not be reassigned",
+		"var x = 1; var x = 2": "'x' is already declared",
+		"y = 2":                "'y' not found",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(nil)
+		require.EqualError(t, err, message, code)
+	}
+
+	_, err := parser.Parse("const x")
+	require.Error(t, err)
+}
+
+type personHost struct {
+	Name string
+	Age  int
+}
+
+func Test_Destructuring(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("list", []interface{}{"a", int32(2)})
+	vars.SetVariable("person", &personHost{Name: "Alice", Age: 31})
+	vars.SetVariable("payload", map[string]interface{}{"name": "Bob", "nickname": nil})
+	vars.SetVariable("split", func() []string {
+		return []string{"DEV", "0042"}
+	})
+
+	for code, expected := range map[string]interface{}{
+		"var [a, b] = list; a + b":                        "a2",
+		"var [a, b, c = 3] = list; c":                     int32(3),
+		"var [prefix, number] = split(); number":          "0042",
+		"var { name, age } = person; name + age":          "Alice31",
+		"var { name, age = 0 } = payload; name + age":     "Bob0",
+		"var { nickname = \"none\" } = payload; nickname": "none",
+		"const { name } = person; name":                   "Alice",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(vars)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_DestructuringErrors(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	vars := NewVariables(nil)
+	vars.SetVariable("list", []interface{}{"a"})
+	vars.SetVariable("person", &personHost{Name: "Alice"})
+
+	for code, message := range map[string]string{
+		"var [a, b] = list":                       "No value for 'b' found in '[a]'",
+		"var { street } = person":                 "No value for 'street' found in '&{Alice 0}'",
+		"var [a] = person":                        "'&{Alice 0}' is not an array",
+		"const { name } = person; name = \"Bob\"": "Constant 'name' can not be reassigned",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(vars)
+		require.EqualError(t, err, message, code)
+	}
+
+	_, err := parser.Parse("var [a, b]")
+	require.Error(t, err)
+}
+
+type addressHost struct {
+	Street string
+	Zip    int32
+	hidden string
+}
+
+type pointHost struct {
+	X float64
+	Y float64
+}
+
+func Test_New(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	require.NoError(t, parser.Types().Register("Address", reflect.TypeOf(addressHost{})))
+	require.NoError(t, parser.Types().RegisterConstructor("Point", func(x float64, y float64) pointHost {
+		return pointHost{X: x, Y: y}
+	}))
+
+	for code, expected := range map[string]interface{}{
+		"new Address { Street = \"Main Street\", zip = 12345 }": &addressHost{Street: "Main Street", Zip: 12345},
+		"new Address":                           &addressHost{},
+		"new Address {}":                        &addressHost{},
+		"new Point(1, 2.5)":                     pointHost{X: 1, Y: 2.5},
+		"new Point(1, 2) { Y = 3 }":             pointHost{X: 1, Y: 3},
+		"new Address { Street = \"A\" }.Street": "A",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+}
+
+func Test_NewErrors(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	require.NoError(t, parser.Types().Register("Address", reflect.TypeOf(addressHost{})))
+	require.Error(t, parser.Types().Register("Number", reflect.TypeOf(0)))
+
+	_, err := parser.Parse("new File { Path = \"/etc/passwd\" }")
+	require.EqualError(t, err, "Type 'File' is not registered at index 4")
+
+	for code, message := range map[string]string{
+		"new Address { City = \"Berlin\" }": "Member with name 'City' not found on 'Address'",
+		"new Address { hidden = \"x\" }":    "Member with name 'hidden' not found on 'Address'",
+		"new Address { Zip = \"abc\" }":     "Member 'Zip': Unable to convert 'abc' to 'int'",
+		"new Address(1)":                    "Type 'Address' has no constructor accepting arguments",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(nil)
+		require.EqualError(t, err, message, code)
+	}
+}
+
+func Test_Functions(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	script, err := parser.Parse(`
+const rate = 0.19
+function gross(net) { net * (1 + rate) }
+function factorial(n) { match (n) { ..1 => 1, _ => n * factorial(n - 1) } }
+gross(100) + factorial(5)`)
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, 239.0, result)
+
+	script, err = parser.Parse("function gross(net) { net * 1.19 }\ngross(1, 2)")
+	require.NoError(t, err)
+	_, err = script.Execute(nil)
+	require.EqualError(t, err, "Function 'gross' expects 1 parameters but 2 were specified")
+}
+
+// mapLoader loads modules from a map
+type mapLoader map[string]string
+
+func (loader mapLoader) Load(path string) (string, error) {
+	code, ok := loader[path]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return code, nil
+}
+
+func Test_Import(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	parser.SetModuleLoader(mapLoader{
+		"common/pricing.ncs": `
+import "common/tax.ncs" as taxes
+let margin = 10
+export const currency = "EUR"
+export function gross(net) { taxes.vat(net + margin) }`,
+		"common/tax.ncs": "export function vat(value) { value * 1.2 }",
+	})
+
+	script, err := parser.Parse(`
+import "common/pricing.ncs"
+pricing.gross(90) + " " + pricing.currency`)
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "120 EUR", result)
+
+	script, err = parser.Parse("import \"common/pricing.ncs\" as prices\nvar { currency } = prices\ncurrency")
+	require.NoError(t, err)
+
+	result, err = script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "EUR", result)
+
+	script, err = parser.Parse("import \"common/pricing.ncs\"\npricing.margin")
+	require.NoError(t, err)
+
+	_, err = script.Execute(nil)
+	require.EqualError(t, err, "Member with name 'margin' not found on 'module 'common/pricing.ncs''")
+}
+
+func Test_ImportErrors(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	_, err := parser.Parse("import \"common/pricing.ncs\"")
+	require.EqualError(t, err, "No module loader configured at index 7")
+
+	parser.SetModuleLoader(mapLoader{
+		"first.ncs":   "import \"second.ncs\"",
+		"second.ncs":  "import \"first.ncs\"",
+		"invalid.ncs": "export gross",
+	})
+
+	for code, message := range map[string]string{
+		"import \"missing.ncs\"":   "Unable to load module 'missing.ncs': not found at index 7",
+		"import \"first.ncs\"":     "Error in module 'first.ncs' at index 7: Error in module 'second.ncs' at index 7: Cyclic import of module 'first.ncs' at index 7",
+		"import \"invalid.ncs\"":   "Error in module 'invalid.ncs' at index 7: Only declarations can be exported at index 7",
+		"import \"2020-data.ncs\"": "Module '2020-data.ncs' has to be imported using 'as namespace' at index 7",
+	} {
+		_, err := parser.Parse(code)
+		require.EqualError(t, err, message, code)
+	}
+}
+
+func Test_FileLoader(t *testing.T) {
+	directory, err := ioutil.TempDir("", "modules")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	require.NoError(t, os.Mkdir(filepath.Join(directory, "common"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "common", "pricing.ncs"), []byte("export const rate = 0.25"), 0644))
+
+	loader := NewFileLoader(directory)
+	code, err := loader.Load("common/pricing.ncs")
+	require.NoError(t, err)
+	require.Equal(t, "export const rate = 0.25", code)
+
+	for _, modulepath := range []string{"../pricing.ncs", "common/../../pricing.ncs", "/etc/passwd", "..\\..\\secret", "common\\pricing.ncs", "C:/secret"} {
+		_, err = loader.Load(modulepath)
+		require.EqualError(t, err, "Invalid module path '"+modulepath+"'", modulepath)
+	}
+
+	parser := NewParser(NewExpressionOperators())
+	parser.SetModuleLoader(loader)
+	script, err := parser.Parse("import \"common/pricing.ncs\"\n100 * pricing.rate")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, 25.0, result)
+}
+
+func Test_Namespaces(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	require.NoError(t, parser.Namespaces().Register("math.sqrt", math.Sqrt))
+	require.NoError(t, parser.Namespaces().Register("math.pi", math.Pi))
+	require.NoError(t, parser.Namespaces().Register("str.pad", func(value string, length int) string {
+		return strings.Repeat("0", length-len(value)) + value
+	}))
+	require.NoError(t, parser.Namespaces().Register("app.config.region", "eu-west"))
+
+	for code, expected := range map[string]interface{}{
+		"math.sqrt(16)":                 4.0,
+		"math.pi > 3":                   true,
+		"str.pad(\"42\", 5)":            "00042",
+		"app.config.region":             "eu-west",
+		"app.config.region ~~ \"^eu-\"": true,
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(nil)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+
+	script, err := parser.Parse("math.cbrt(27)")
+	require.NoError(t, err)
+	_, err = script.Execute(nil)
+	require.EqualError(t, err, "Member with name 'cbrt' not found on 'math'")
+}
+
+func Test_VariablesShadowNamespaces(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	require.NoError(t, parser.Namespaces().Register("app.config.region", "eu-west"))
+
+	script, err := parser.Parse("app.config.region")
+	require.NoError(t, err)
+
+	variables := NewVariables(nil)
+	variables.SetVariable("app", map[string]interface{}{})
+	_, err = script.Execute(variables)
+	require.EqualError(t, err, "Member with name 'config' not found on 'map[]'")
+
+	script, err = parser.Parse("var app = 1\napp")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), result)
+}
+
+func Test_NamespaceRegistrationErrors(t *testing.T) {
+	namespaces := NewNamespaces()
+	require.NoError(t, namespaces.Register("app.config.region", "eu-west"))
+
+	require.EqualError(t, namespaces.Register("sin", math.Sin), "'sin' has no namespace")
+	require.EqualError(t, namespaces.Register("math..sin", math.Sin), "'math..sin' is no valid name")
+	require.EqualError(t, namespaces.Register("app.config.region", "us-east"), "'app.config.region' is already registered")
+	require.EqualError(t, namespaces.Register("app.config.region.zone", "a"), "'app.config.region' is not a namespace")
+}
+
+type orderStatus int
+
+const (
+	statusActive orderStatus = iota + 1
+	statusShipped
+	statusCancelled
+)
+
+type shipmentHost struct {
+	Status  orderStatus
+	Carrier string
+}
+
+func Test_Enums(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	require.NoError(t, parser.Conversions().RegisterEnum("Status", map[string]interface{}{
+		"Active":    statusActive,
+		"Shipped":   statusShipped,
+		"Cancelled": statusCancelled,
+	}))
+
+	variables := NewVariables(nil)
+	variables.SetVariable("order", &shipmentHost{Status: statusShipped})
+	variables.SetVariable("cancel", func(status orderStatus) bool { return status == statusCancelled })
+
+	for code, expected := range map[string]interface{}{
+		"Status.Active":                                        statusActive,
+		"order.Status == Status.Shipped":                       true,
+		"order.Status == Status.Active":                        false,
+		"string(order.Status)":                                 "Shipped",
+		"Status(\"Cancelled\")":                                statusCancelled,
+		"Status(1)":                                            statusActive,
+		"order.Status is Status":                               true,
+		"typeof(Status.Active)":                                "Status",
+		"cancel(\"Cancelled\")":                                true,
+		"cancel(Status.Active)":                                false,
+		"match (order.Status) { Status.Shipped => 1, _ => 0 }": int32(1),
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		result, err := script.Execute(variables)
+		require.NoError(t, err, code)
+		require.Equal(t, expected, result, code)
+	}
+
+	for code, message := range map[string]string{
+		"Status.Unknown":   "Member with name 'Unknown' not found on 'Status'",
+		"Status(\"Lost\")": "'Lost' is no value of enum 'Status'",
+		"Status(7)":        "'7' is no value of enum 'Status'",
+	} {
+		script, err := parser.Parse(code)
+		require.NoError(t, err, code)
+
+		_, err = script.Execute(variables)
+		require.EqualError(t, err, message, code)
+	}
+}
+
+func Test_StringEnums(t *testing.T) {
+	type region string
+
+	parser := NewParser(NewExpressionOperators())
+	require.NoError(t, parser.Conversions().RegisterEnum("Region", map[string]interface{}{
+		"Europe":  region("eu-west"),
+		"America": region("us-east"),
+	}))
+
+	script, err := parser.Parse("string(Region(\"eu-west\")) + \" \" + string(Region.America)")
+	require.NoError(t, err)
+
+	result, err := script.Execute(nil)
+	require.NoError(t, err)
+	require.Equal(t, "Europe America", result)
+}
+
+func Test_EnumRegistrationErrors(t *testing.T) {
+	conversions := NewConversions()
+	require.EqualError(t, conversions.RegisterEnum("Status", map[string]interface{}{}), "Enum 'Status' has no values")
+	require.EqualError(t, conversions.RegisterEnum("Status", map[string]interface{}{"Active": 1.5}), "Values of enum 'Status' have to be integers or strings")
+	require.EqualError(t, conversions.RegisterEnum("Status", map[string]interface{}{"Active": statusActive, "Shipped": 2}), "Values of enum 'Status' have to be of the same type")
+	require.EqualError(t, conversions.RegisterEnum("Status", map[string]interface{}{"not valid": statusActive}), "'not valid' is no valid name for a value of enum 'Status'")
+	require.EqualError(t, conversions.RegisterEnum("int", map[string]interface{}{"Active": statusActive}), "'int' is a builtin cast and can't be registered")
+}
+
+func Test_EnumOfBuiltinTypeIsRejected(t *testing.T) {
+	parser := NewParser(NewExpressionOperators())
+	require.EqualError(t, parser.Conversions().RegisterEnum("Level", map[string]interface{}{"Low": 1, "High": 2}),
+		"Values of enum 'Level' have to be of a named type like 'type Level int'")
+	require.EqualError(t, parser.Conversions().RegisterEnum("Color", map[string]interface{}{"Red": "red"}),
+		"Values of enum 'Color' have to be of a named type like 'type Color string'")
+}
+
+func Test_OperatorTypeValuesAreStable(t *testing.T) {
+	require.Equal(t, OperatorType(20), OP_Assign)
+	require.Equal(t, OperatorType(39), OP_Lambda)
+	require.Equal(t, OperatorType(40), OP_FromEnd)
 }
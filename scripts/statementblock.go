@@ -2,22 +2,36 @@ package scripts
 
 // StatementBlock series of statements
 type StatementBlock struct {
-	Body     []Token
-	IsMethod bool
+	Body      []Token
+	IsMethod  bool
+	positions []int
 }
 
 // Execute executes the statement block
 func (block *StatementBlock) Execute(variables *Variables) (interface{}, error) {
+	result, _, err := block.execute(variables)
+	return result, err
+}
+
+// execute executes the statements of the block
+//
+// **Returns**
+//   result of the last statement, any error and the script position of the statement which failed
+func (block *StatementBlock) execute(variables *Variables) (interface{}, int, error) {
 	blockvariables := NewVariables(variables)
 	var result interface{}
 	var err error
 
-	for _, token := range block.Body {
+	for i, token := range block.Body {
 		result, err = token.Execute(blockvariables)
 		if err != nil {
-			return nil, err
+			position := 0
+			if i < len(block.positions) {
+				position = block.positions[i]
+			}
+			return nil, position, err
 		}
 	}
 
-	return result, nil
+	return result, 0, nil
 }
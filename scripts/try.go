@@ -0,0 +1,116 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ScriptError error thrown by a script or raised while executing it
+//
+// Errors caught by a catch block are provided as ScriptError, so scripts can access
+// message, kind and position of the error.
+type ScriptError struct {
+	Message  string
+	Kind     string
+	Position int
+	Value    interface{}
+	err      error
+}
+
+// Error returns the error message
+func (err *ScriptError) Error() string {
+	return err.Message
+}
+
+// Unwrap returns the go error which was raised, nil if the error was thrown by a script
+func (err *ScriptError) Unwrap() error {
+	return err.err
+}
+
+// newScriptError converts an error to a ScriptError
+//
+// The kind of a go error is the name of its type like 'OverflowError', plain errors
+// created by the errors or fmt packages are of kind 'Error'.
+func newScriptError(err error, position int) *ScriptError {
+	var scripterror *ScriptError
+	if errors.As(err, &scripterror) {
+		return scripterror
+	}
+
+	kind := "Error"
+	errortype := reflect.TypeOf(err)
+	if errortype.Kind() == reflect.Ptr {
+		errortype = errortype.Elem()
+	}
+	if errortype.PkgPath() != "errors" && errortype.PkgPath() != "fmt" && len(errortype.Name()) > 0 {
+		kind = errortype.Name()
+	}
+
+	return &ScriptError{
+		Message:  err.Error(),
+		Kind:     kind,
+		Position: position,
+		err:      err}
+}
+
+// Throw throws an error
+//
+// Thrown errors are rethrown unchanged, any other value is thrown as ScriptError of kind
+// 'Error' with the string representation of the value as message.
+type Throw struct {
+	Value    Token
+	position int
+}
+
+// Execute throws the error
+func (throw *Throw) Execute(variables *Variables) (interface{}, error) {
+	value, err := throw.Value.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	if err, ok := value.(error); ok {
+		return nil, err
+	}
+
+	return nil, &ScriptError{
+		Message:  fmt.Sprintf("%v", value),
+		Kind:     "Error",
+		Position: throw.position,
+		Value:    value}
+}
+
+// Try executes a block and handles errors raised by it
+//
+// If the body fails the error is provided to the catch block as ScriptError. The finally
+// block is executed in any case.
+type Try struct {
+	Body     *StatementBlock
+	Variable string
+	Catch    *StatementBlock
+	Finally  *StatementBlock
+}
+
+// Execute executes the try statement
+func (try *Try) Execute(variables *Variables) (interface{}, error) {
+	result, position, err := try.Body.execute(variables)
+	if err != nil && try.Catch != nil {
+		catchvariables := NewVariables(variables)
+		if len(try.Variable) > 0 {
+			catchvariables.SetVariable(try.Variable, newScriptError(err, position))
+		}
+		result, err = try.Catch.Execute(catchvariables)
+	}
+
+	if try.Finally != nil {
+		if _, finallyerr := try.Finally.Execute(variables); finallyerr != nil {
+			return nil, finallyerr
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package scripts
+
+import (
+	"reflect"
+	"sort"
+)
+
+const TYPE_NULL = "null"
+const TYPE_OBJECT = "object"
+const TYPE_RANGE = "range"
+
+// TypeName name of a type used as operand of the type operators 'is' and 'as'
+//
+// Type names are the names of cast target types including the ones registered in
+// Conversions. Additionally 'null' matches null values, 'object' any other value and
+// 'range' ranges like 1..5.
+type TypeName struct {
+	Name        string
+	conversions *Conversions
+}
+
+// Execute returns the name of the type
+func (typename *TypeName) Execute(variables *Variables) (interface{}, error) {
+	return typename.Name, nil
+}
+
+// Matches determines whether a value is of this type
+func (typename *TypeName) Matches(value interface{}) bool {
+	switch typename.Name {
+	case TYPE_NULL:
+		return value == nil
+	case TYPE_OBJECT:
+		return value != nil
+	case TYPE_RANGE:
+		_, ok := value.(Range)
+		return ok
+	}
+
+	if value == nil {
+		return false
+	}
+
+	valuetype := reflect.TypeOf(value)
+	if typename.conversions != nil {
+		if registered, ok := typename.conversions.names[typename.Name]; ok {
+			return registered.target == valuetype
+		}
+	}
+
+	switch typename.Name {
+	case CAST_ARRAY:
+		return valuetype.Kind() == reflect.Slice || valuetype.Kind() == reflect.Array
+	default:
+		return castTypes[valuetype] == typename.Name
+	}
+}
+
+// TypeOf determines the name of the type of a value
+type TypeOf struct {
+	Data        Token
+	conversions *Conversions
+}
+
+// Execute returns the type name of the value
+func (typeof *TypeOf) Execute(variables *Variables) (interface{}, error) {
+	value, err := typeof.Data.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return typeof.conversions.typeName(value), nil
+}
+
+// typeName determines the name of the type of a value
+//
+// Registered types result in the name they were registered with last, maps are objects and
+// types unknown to scripts result in the name of the go type.
+func (conversions *Conversions) typeName(value interface{}) string {
+	if value == nil {
+		return TYPE_NULL
+	}
+
+	valuetype := reflect.TypeOf(value)
+	if conversions != nil {
+		if registered, ok := conversions.types[valuetype]; ok && conversions.names[registered.name] == registered {
+			return registered.name
+		}
+
+		// the last conversion registered for the type was replaced by a conversion of the same name
+		names := make([]string, 0, len(conversions.names))
+		for name, registered := range conversions.names {
+			if registered.target == valuetype {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			return names[0]
+		}
+	}
+
+	if name, ok := castTypes[valuetype]; ok {
+		return name
+	}
+	if _, ok := value.(Range); ok {
+		return TYPE_RANGE
+	}
+
+	switch valuetype.Kind() {
+	case reflect.Slice, reflect.Array:
+		return CAST_ARRAY
+	case reflect.Map:
+		return TYPE_OBJECT
+	default:
+		return valuetype.String()
+	}
+}
+
+// isTypeName determines whether a name can be used as operand of the type operators
+func (conversions *Conversions) isTypeName(name string) bool {
+	return name == TYPE_NULL || name == TYPE_OBJECT || name == TYPE_RANGE || conversions.IsTargetType(name)
+}
+
+// typeTest evaluates the type operators 'is' and 'as'
+//
+// 'is' determines whether the value is of the specified type, 'as' returns the value if it
+// is of the specified type and null otherwise. Unlike casts no conversion takes place.
+func (op *Operator) typeTest(variables *Variables) (interface{}, error) {
+	value, err := op.LHS.Execute(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := op.RHS.(*TypeName).Matches(value)
+	if op.Type == OP_Is {
+		return matches, nil
+	}
+
+	if matches {
+		return value, nil
+	}
+	return nil, nil
+}
@@ -1,11 +1,18 @@
 package scripts
 
 // Variable holds reference to a script variable
+//
+// If no variable with the name exists the namespace or enum with the same name is used.
 type Variable struct {
-	Name string
+	Name      string
+	namespace MemberHost
 }
 
 // Execute returns value of variable
 func (variable *Variable) Execute(variables *Variables) (interface{}, error) {
-	return variables.GetVariable(variable.Name)
+	value, err := variables.GetVariable(variable.Name)
+	if err != nil && variable.namespace != nil {
+		return variable.namespace, nil
+	}
+	return value, err
 }
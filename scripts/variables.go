@@ -4,8 +4,10 @@ import "fmt"
 
 // Variables variable pool for token execution
 type Variables struct {
-	parent *Variables
-	values map[string]interface{}
+	parent    *Variables
+	values    map[string]interface{}
+	constants map[string]bool
+	options   *Options
 }
 
 // NewVariables creates new variables
@@ -37,3 +39,50 @@ func (vars *Variables) GetVariable(name string) (interface{}, error) {
 func (vars *Variables) SetVariable(name string, value interface{}) {
 	vars.values[name] = value
 }
+
+// GetOptions get execution options of this provider or the nearest parent defining options
+func (vars *Variables) GetOptions() Options {
+	for current := vars; current != nil; current = current.parent {
+		if current.options != nil {
+			return *current.options
+		}
+	}
+
+	return Options{}
+}
+
+// SetOptions set execution options used for tokens executed with this provider
+func (vars *Variables) SetOptions(options Options) {
+	vars.options = &options
+}
+
+// declareVariable declares a variable in this provider shadowing variables of parents
+func (vars *Variables) declareVariable(name string, value interface{}, constant bool) error {
+	if _, exists := vars.values[name]; exists {
+		return fmt.Errorf("'%s' is already declared", name)
+	}
+
+	vars.values[name] = value
+	if constant {
+		if vars.constants == nil {
+			vars.constants = make(map[string]bool)
+		}
+		vars.constants[name] = true
+	}
+	return nil
+}
+
+// assignVariable assigns a value to the variable in the nearest provider defining it
+func (vars *Variables) assignVariable(name string, value interface{}) error {
+	for current := vars; current != nil; current = current.parent {
+		if _, exists := current.values[name]; exists {
+			if current.constants[name] {
+				return fmt.Errorf("Constant '%s' can not be reassigned", name)
+			}
+			current.values[name] = value
+			return nil
+		}
+	}
+
+	return fmt.Errorf("'%s' not found", name)
+}